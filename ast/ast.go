@@ -12,6 +12,8 @@ import (
 type Node interface {
 	node()
 	String() string
+	// Pos returns position of the first character of the node.
+	Pos() token.Position
 }
 
 // Expr represents an expression that can be evaluated to a value.
@@ -24,29 +26,34 @@ type Expr interface {
 type Stmt interface {
 	Node
 	stmt()
+	// End returns position of the last token of the statement.
+	End() token.Position
 }
 
 func (*Ident) node() {}
 
 func (*Literal) node() {}
 
-func (*AssignExpr) node()   {}
-func (*BinaryExpr) node()   {}
-func (*CallExpr) node()     {}
-func (*GetExpr) node()      {}
-func (*GroupingExpr) node() {}
-func (*LogicalExpr) node()  {}
-func (*SetExpr) node()      {}
-func (*SuperExpr) node()    {}
-func (*ThisExpr) node()     {}
-func (*UnaryExpr) node()    {}
-func (*VariableExpr) node() {}
+func (*AssignExpr) node()        {}
+func (*BinaryExpr) node()        {}
+func (*CallExpr) node()          {}
+func (*GetExpr) node()           {}
+func (*GroupingExpr) node()      {}
+func (*InterpolationExpr) node() {}
+func (*LogicalExpr) node()       {}
+func (*SetExpr) node()           {}
+func (*SuperExpr) node()         {}
+func (*ThisExpr) node()          {}
+func (*UnaryExpr) node()         {}
+func (*VariableExpr) node()      {}
 
 func (*BlockStmt) node()    {}
 func (*ClassStmt) node()    {}
 func (*ExprStmt) node()     {}
+func (*ForStmt) node()      {}
 func (*FunctionStmt) node() {}
 func (*IfStmt) node()       {}
+func (*ImportStmt) node()   {}
 func (*PrintStmt) node()    {}
 func (*ReturnStmt) node()   {}
 func (*VarStmt) node()      {}
@@ -54,18 +61,24 @@ func (*WhileStmt) node()    {}
 
 // Ident represents an identifier.
 type Ident struct {
-	Name string
+	NamePos token.Position
+	Name    string
 }
 
+func (ident *Ident) Pos() token.Position { return ident.NamePos }
+
 func (ident *Ident) String() string { return ident.Name }
 
 type Literal struct {
-	Token token.Token
-	Value string
+	ValuePos token.Position
+	Token    token.Token
+	Value    string
 }
 
 func (*Literal) expr() {}
 
+func (lit *Literal) Pos() token.Position { return lit.ValuePos }
+
 func (lit *Literal) String() string {
 	switch lit.Token {
 	case token.Nil:
@@ -74,10 +87,8 @@ func (lit *Literal) String() string {
 		return "true"
 	case token.False:
 		return "false"
-	case token.Number, token.String:
-		return lit.Value
 	}
-	panic("unknown Literal.")
+	return lit.Value
 }
 
 type (
@@ -95,49 +106,84 @@ type (
 		Arguments []Expr
 	}
 	GetExpr struct {
-		Object Expr
-		Name   string
+		Object  Expr
+		NamePos token.Position
+		Name    string
+		// Cache is owned by the interpreter, which keeps the inline cache of the
+		// property lookup in it. It isn't syntax: Walk, Rewrite, String and
+		// Marshal ignore it, and other packages must leave it alone.
+		Cache interface{} `json:"-"`
 	}
 	GroupingExpr struct {
+		Lparen     token.Position
 		Expression Expr
 	}
+	// InterpolationExpr is a string with embedded expressions, such as "x = ${x}".
+	// Strings are the parts of the string around Exprs, so there is one more of them.
+	InterpolationExpr struct {
+		Quote   token.Position
+		Strings []string
+		Exprs   []Expr
+	}
 	LogicalExpr struct {
 		Left     Expr
 		Operator token.Token
 		Right    Expr
 	}
 	SetExpr struct {
-		Object Expr
-		Name   string
-		Value  Expr
+		Object  Expr
+		NamePos token.Position
+		Name    string
+		Value   Expr
+		// Cache is owned by the interpreter like the cache of GetExpr.
+		Cache interface{} `json:"-"`
 	}
 	SuperExpr struct {
-		// Method  Ident
-		Keyword token.Token
-		Method  token.Token
+		KeywordPos token.Position
+		Keyword    token.Token
+		MethodPos  token.Position
+		Method     string
+	}
+	ThisExpr struct {
+		ThisPos token.Position
 	}
-	ThisExpr struct {}
 	UnaryExpr struct {
+		OpPos    token.Position
 		Operator token.Token
 		Right    Expr
 	}
 	VariableExpr struct {
+		NamePos  token.Position
 		Name     string
-		Distance int // -1 represents global variable.
+		Distance int `json:"-"` // -1 represents global variable.
 	}
 )
 
-func (*AssignExpr) expr()   {}
-func (*BinaryExpr) expr()   {}
-func (*CallExpr) expr()     {}
-func (*GetExpr) expr()      {}
-func (*GroupingExpr) expr() {}
-func (*LogicalExpr) expr()  {}
-func (*SetExpr) expr()      {}
-func (*SuperExpr) expr()    {}
-func (*ThisExpr) expr()     {}
-func (*UnaryExpr) expr()    {}
-func (*VariableExpr) expr() {}
+func (*AssignExpr) expr()        {}
+func (*BinaryExpr) expr()        {}
+func (*CallExpr) expr()          {}
+func (*GetExpr) expr()           {}
+func (*GroupingExpr) expr()      {}
+func (*InterpolationExpr) expr() {}
+func (*LogicalExpr) expr()       {}
+func (*SetExpr) expr()           {}
+func (*SuperExpr) expr()         {}
+func (*ThisExpr) expr()          {}
+func (*UnaryExpr) expr()         {}
+func (*VariableExpr) expr()      {}
+
+func (e *AssignExpr) Pos() token.Position        { return e.Left.Pos() }
+func (e *BinaryExpr) Pos() token.Position        { return e.Left.Pos() }
+func (e *CallExpr) Pos() token.Position          { return e.Callee.Pos() }
+func (e *GetExpr) Pos() token.Position           { return e.Object.Pos() }
+func (e *GroupingExpr) Pos() token.Position      { return e.Lparen }
+func (e *InterpolationExpr) Pos() token.Position { return e.Quote }
+func (e *LogicalExpr) Pos() token.Position       { return e.Left.Pos() }
+func (e *SetExpr) Pos() token.Position           { return e.Object.Pos() }
+func (e *SuperExpr) Pos() token.Position         { return e.KeywordPos }
+func (e *ThisExpr) Pos() token.Position          { return e.ThisPos }
+func (e *UnaryExpr) Pos() token.Position         { return e.OpPos }
+func (e *VariableExpr) Pos() token.Position      { return e.NamePos }
 
 func (e *AssignExpr) String() string {
 	return fmt.Sprintf("%s = %s", e.Left, e.Value)
@@ -163,6 +209,17 @@ func (e *GroupingExpr) String() string {
 	return fmt.Sprintf("(%s)", e.Expression)
 }
 
+func (e *InterpolationExpr) String() string {
+	var b strings.Builder
+	for i, s := range e.Strings {
+		b.WriteString(s)
+		if i < len(e.Exprs) {
+			fmt.Fprintf(&b, "${%s}", e.Exprs[i])
+		}
+	}
+	return b.String()
+}
+
 func (e *LogicalExpr) String() string {
 	return fmt.Sprintf("%s %s %s", e.Left, e.Operator, e.Right)
 }
@@ -172,7 +229,7 @@ func (e *SetExpr) String() string {
 }
 
 func (e *SuperExpr) String() string {
-	return "TODO"
+	return "super." + e.Method
 }
 
 func (e *ThisExpr) String() string {
@@ -189,39 +246,75 @@ func (e *VariableExpr) String() string {
 
 type (
 	BlockStmt struct {
+		Lbrace     token.Position
 		Statements []Stmt
+		Rbrace     token.Position
 	}
 	ClassStmt struct {
+		ClassPos   token.Position
+		NamePos    token.Position
 		Name       string
 		SuperClass VariableExpr
 		Methods    []*FunctionStmt
+		Rbrace     token.Position
 	}
 	ExprStmt struct {
 		Expression Expr
+		Semicolon  token.Position
+	}
+	// ForStmt is kept as it is written in source.
+	// Desugar returns the equivalent while loop.
+	ForStmt struct {
+		ForPos      token.Position
+		Initializer Stmt // nil if omitted
+		Condition   Expr // nil if omitted
+		Increment   Expr // nil if omitted
+		Body        Stmt
 	}
 	FunctionStmt struct {
+		FunPos        token.Position // position of name for methods
+		NamePos       token.Position
 		Name          string
 		Params        []*Ident
 		Body          []Stmt
+		Rbrace        token.Position
 		IsInitializer bool
 	}
 	IfStmt struct {
+		IfPos      token.Position
 		Condition  Expr
 		ThenBranch Stmt
 		ElseBranch Stmt
 	}
+	// ImportStmt is `import "path" as Name;`, or `from "path" import Names;`
+	// if Names is not empty.
+	ImportStmt struct {
+		ImportPos token.Position // position of "import" or "from"
+		PathPos   token.Position
+		Path      string
+		Name      *Ident
+		Names     []*Ident
+		Semicolon token.Position
+	}
 	PrintStmt struct {
+		PrintPos   token.Position
 		Expression Expr
+		Semicolon  token.Position
 	}
 	ReturnStmt struct {
-		Keyword token.Token
-		Value   Expr
+		ReturnPos token.Position
+		Keyword   token.Token
+		Value     Expr
+		Semicolon token.Position
 	}
 	VarStmt struct {
+		VarPos      token.Position
 		Name        *Ident
 		Initializer Expr
+		Semicolon   token.Position
 	}
 	WhileStmt struct {
+		WhilePos  token.Position
 		Condition Expr
 		Body      Stmt
 	}
@@ -230,13 +323,81 @@ type (
 func (*BlockStmt) stmt()    {}
 func (*ClassStmt) stmt()    {}
 func (*ExprStmt) stmt()     {}
+func (*ForStmt) stmt()      {}
 func (*FunctionStmt) stmt() {}
 func (*IfStmt) stmt()       {}
+func (*ImportStmt) stmt()   {}
 func (*PrintStmt) stmt()    {}
 func (*ReturnStmt) stmt()   {}
 func (*VarStmt) stmt()      {}
 func (*WhileStmt) stmt()    {}
 
+func (s *BlockStmt) Pos() token.Position    { return s.Lbrace }
+func (s *ClassStmt) Pos() token.Position    { return s.ClassPos }
+func (s *ExprStmt) Pos() token.Position     { return s.Expression.Pos() }
+func (s *ForStmt) Pos() token.Position      { return s.ForPos }
+func (s *FunctionStmt) Pos() token.Position { return s.FunPos }
+func (s *IfStmt) Pos() token.Position       { return s.IfPos }
+func (s *ImportStmt) Pos() token.Position   { return s.ImportPos }
+func (s *PrintStmt) Pos() token.Position    { return s.PrintPos }
+func (s *ReturnStmt) Pos() token.Position   { return s.ReturnPos }
+func (s *VarStmt) Pos() token.Position      { return s.VarPos }
+func (s *WhileStmt) Pos() token.Position    { return s.WhilePos }
+
+func (s *BlockStmt) End() token.Position    { return s.Rbrace }
+func (s *ClassStmt) End() token.Position    { return s.Rbrace }
+func (s *ExprStmt) End() token.Position     { return s.Semicolon }
+func (s *ForStmt) End() token.Position      { return s.Body.End() }
+func (s *FunctionStmt) End() token.Position { return s.Rbrace }
+func (s *ImportStmt) End() token.Position   { return s.Semicolon }
+func (s *PrintStmt) End() token.Position    { return s.Semicolon }
+func (s *ReturnStmt) End() token.Position   { return s.Semicolon }
+func (s *VarStmt) End() token.Position      { return s.Semicolon }
+func (s *WhileStmt) End() token.Position    { return s.Body.End() }
+
+func (s *IfStmt) End() token.Position {
+	if s.ElseBranch != nil {
+		return s.ElseBranch.End()
+	}
+	return s.ThenBranch.End()
+}
+
+// Desugar returns the while loop which is equivalent to the for loop.
+func (s *ForStmt) Desugar() Stmt {
+	body := s.Body
+	if s.Increment != nil {
+		body = &BlockStmt{
+			Statements: []Stmt{
+				body,
+				&ExprStmt{
+					Expression: s.Increment,
+				},
+			},
+		}
+	}
+	condition := s.Condition
+	if condition == nil {
+		condition = &Literal{
+			Token: token.True,
+			Value: "true",
+		}
+	}
+	body = &WhileStmt{
+		WhilePos:  s.ForPos,
+		Condition: condition,
+		Body:      body,
+	}
+	if s.Initializer != nil {
+		body = &BlockStmt{
+			Statements: []Stmt{
+				s.Initializer,
+				body,
+			},
+		}
+	}
+	return body
+}
+
 func (s *BlockStmt) String() string {
 	var sb strings.Builder
 	sb.WriteString("{ ")
@@ -255,6 +416,10 @@ func (s *ExprStmt) String() string {
 	return s.Expression.String() + ";"
 }
 
+func (s *ForStmt) String() string {
+	return s.Desugar().String()
+}
+
 func (s *FunctionStmt) String() string {
 	var sb strings.Builder
 	sb.WriteString("fun ")
@@ -286,6 +451,18 @@ func (s *IfStmt) String() string {
 	return sb.String()
 }
 
+func (s *ImportStmt) String() string {
+	path := `"` + strings.Replace(s.Path, `"`, `\"`, -1) + `"`
+	if len(s.Names) == 0 {
+		return "import " + path + " as " + s.Name.Name + ";"
+	}
+	names := make([]string, len(s.Names))
+	for i, name := range s.Names {
+		names[i] = name.Name
+	}
+	return "from " + path + " import " + strings.Join(names, ", ") + ";"
+}
+
 func (s *PrintStmt) String() string {
 	var sb strings.Builder
 	sb.WriteString("print ")
@@ -306,8 +483,10 @@ func (s *VarStmt) String() string {
 	var sb strings.Builder
 	sb.WriteString("var ")
 	sb.WriteString(s.Name.String())
-	sb.WriteString(" = ")
-	sb.WriteString(s.Initializer.String())
+	if s.Initializer != nil {
+		sb.WriteString(" = ")
+		sb.WriteString(s.Initializer.String())
+	}
 	sb.WriteRune(';')
 	return sb.String()
 }
@@ -0,0 +1,13 @@
+package ast
+
+import "github.com/ziyoung/lox-go/token"
+
+// Comment represents a line comment starting with "//".
+// Comments are not part of the syntax tree; the parser collects them separately.
+type Comment struct {
+	Slash token.Position
+	Text  string // comment text including "//"
+}
+
+// Pos returns position of the comment.
+func (c *Comment) Pos() token.Position { return c.Slash }
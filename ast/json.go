@@ -0,0 +1,241 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ziyoung/lox-go/token"
+)
+
+// kinds maps names of node types to the types.
+var kinds = map[string]reflect.Type{}
+
+func init() {
+	for _, n := range []Node{
+		&Ident{}, &Literal{},
+		&AssignExpr{}, &BinaryExpr{}, &CallExpr{}, &GetExpr{}, &GroupingExpr{},
+		&InterpolationExpr{}, &LogicalExpr{}, &SetExpr{}, &SuperExpr{}, &ThisExpr{}, &UnaryExpr{}, &VariableExpr{},
+		&BlockStmt{}, &ClassStmt{}, &ExprStmt{}, &ForStmt{}, &FunctionStmt{}, &IfStmt{},
+		&ImportStmt{}, &PrintStmt{}, &ReturnStmt{}, &VarStmt{}, &WhileStmt{},
+	} {
+		t := reflect.TypeOf(n).Elem()
+		kinds[t.Name()] = t
+	}
+}
+
+var (
+	positionType = reflect.TypeOf(token.Position{})
+	tokenType    = reflect.TypeOf(token.Token(0))
+)
+
+// jsonName returns the name of the member of a field, which is the name of the
+// field in lower camel case.
+func jsonName(field string) string {
+	r, n := utf8.DecodeRuneInString(field)
+	return string(unicode.ToLower(r)) + field[n:]
+}
+
+// Marshal returns the JSON encoding of statements, which is an array of nodes.
+//
+// A node is an object whose "kind" is the name of its type, such as "BinaryExpr".
+// It has a member for each field of the type except caches and distances of
+// variables resolved by the resolver, named in lower camel case, such as "operator"
+// and "opPos". Positions are objects with "offset", "line" and "column", tokens are
+// strings such as "+", and absent nodes, such as the superclass of a class without
+// one, are null. Every node also has "pos", and statements have "end", which
+// Unmarshal ignores. Variables are unmarshaled unresolved.
+func Marshal(statements []Stmt) ([]byte, error) {
+	e := &encoder{}
+	e.value(reflect.ValueOf(statements))
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (e *encoder) raw(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	e.buf.Write(b)
+}
+
+func (e *encoder) value(v reflect.Value) {
+	switch {
+	case v.Type() == positionType || v.Type() == tokenType:
+		e.raw(v.Interface())
+	case v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		e.value(v.Elem())
+	case v.Kind() == reflect.Struct:
+		// a node embedded by value is absent when it is zero.
+		if reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface()) {
+			e.buf.WriteString("null")
+			return
+		}
+		e.node(v)
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		e.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.value(v.Index(i))
+		}
+		e.buf.WriteByte(']')
+	default:
+		e.raw(v.Interface())
+	}
+}
+
+// node encodes v, which is an addressable struct of a node type.
+func (e *encoder) node(v reflect.Value) {
+	t := v.Type()
+	if kinds[t.Name()] != t {
+		if e.err == nil {
+			e.err = fmt.Errorf("ast: cannot marshal %s", t)
+		}
+		return
+	}
+	e.buf.WriteString(`{"kind":`)
+	e.raw(t.Name())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("json") == "-" {
+			continue
+		}
+		e.buf.WriteByte(',')
+		e.raw(jsonName(t.Field(i).Name))
+		e.buf.WriteByte(':')
+		e.value(v.Field(i))
+	}
+	n := v.Addr().Interface().(Node)
+	e.buf.WriteString(`,"pos":`)
+	e.value(reflect.ValueOf(n.Pos()))
+	if stmt, ok := n.(Stmt); ok {
+		e.buf.WriteString(`,"end":`)
+		e.value(reflect.ValueOf(stmt.End()))
+	}
+	e.buf.WriteByte('}')
+}
+
+// Unmarshal parses statements encoded by Marshal.
+func Unmarshal(data []byte) ([]Stmt, error) {
+	var statements []Stmt
+	if err := decode(data, reflect.ValueOf(&statements).Elem()); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// decode decodes data into v, which is settable.
+func decode(data json.RawMessage, v reflect.Value) error {
+	isNull := bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+	switch {
+	case v.Type() == positionType:
+		return json.Unmarshal(data, v.Addr().Interface())
+	case v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		n, err := decodeNode(data)
+		if err != nil {
+			return err
+		}
+		if !n.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("%s is not %s", n.Elem().Type().Name(), describe(v.Type()))
+		}
+		v.Set(n)
+	case v.Kind() == reflect.Struct:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		n, err := decodeNode(data)
+		if err != nil {
+			return err
+		}
+		if n.Elem().Type() != v.Type() {
+			return fmt.Errorf("%s is not %s", n.Elem().Type().Name(), v.Type().Name())
+		}
+		v.Set(n.Elem())
+	case v.Kind() == reflect.Slice:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decode(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	return nil
+}
+
+// decodeNode decodes an object of a node into a pointer to the node.
+func decodeNode(data json.RawMessage) (reflect.Value, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return reflect.Value{}, err
+	}
+	var kind string
+	if err := json.Unmarshal(obj["kind"], &kind); err != nil {
+		return reflect.Value{}, fmt.Errorf("node without kind")
+	}
+	t, ok := kinds[kind]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown kind %q", kind)
+	}
+	n := reflect.New(t)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("json") == "-" {
+			continue
+		}
+		name := jsonName(t.Field(i).Name)
+		if data, ok := obj[name]; ok {
+			if err := decode(data, n.Elem().Field(i)); err != nil {
+				return reflect.Value{}, fmt.Errorf("%s.%s: %s", kind, name, err)
+			}
+		}
+	}
+	if v, ok := n.Interface().(*VariableExpr); ok {
+		v.Distance = -1
+	}
+	return n, nil
+}
+
+func describe(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf((*Expr)(nil)).Elem():
+		return "an expression"
+	case reflect.TypeOf((*Stmt)(nil)).Elem():
+		return "a statement"
+	}
+	return t.Elem().Name()
+}
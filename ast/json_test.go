@@ -0,0 +1,95 @@
+package ast_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/resolver"
+)
+
+func TestJSON(t *testing.T) {
+	input := `import "m" as m;
+from "m" import a, b;
+var x = -1 + 2 * (3 - "s") or nil and !"${x}, ${"y"}";
+fun f(a, b) {
+  x = a.b.c(1, false);
+  if (a) print a; else { return; }
+  while (b) b.c = this;
+  for (var i = 0; i < 1; i = i + 1) {}
+  for (;;) return 1;
+}
+class A {
+  init() {}
+}`
+	statements, err := parser.ParseStmts(input)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	data, err := ast.Marshal(statements)
+	if err != nil {
+		t.Fatalf("marshal failed. error: %s", err)
+	}
+	got, err := ast.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal failed. error: %s", err)
+	}
+	if !reflect.DeepEqual(got, statements) {
+		t.Errorf("statements are not the same after marshaling. got %s", data)
+	}
+	if !strings.Contains(string(data), `"superClass":null`) {
+		t.Errorf("absent superclass should be null. got %s", data)
+	}
+
+}
+
+func TestMarshalResolved(t *testing.T) {
+	statements, err := parser.ParseStmts("var a = 1;\nfun f(b) {\n  a = b;\n  return a + b;\n}")
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	data, err := ast.Marshal(statements)
+	if err != nil {
+		t.Fatalf("marshal failed. error: %s", err)
+	}
+	// distances set by the resolver are not syntax.
+	resolver.Reset()
+	for _, stmt := range statements {
+		resolver.Resolve(stmt)
+	}
+	resolved, err := ast.Marshal(statements)
+	if err != nil {
+		t.Fatalf("marshal failed. error: %s", err)
+	}
+	if string(resolved) != string(data) || strings.Contains(string(data), "distance") {
+		t.Errorf("distances should not be marshaled. got %s", resolved)
+	}
+	got, err := ast.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unmarshal failed. error: %s", err)
+	}
+	b := got[1].(*ast.FunctionStmt).Body[1].(*ast.ReturnStmt).Value.(*ast.BinaryExpr).Right.(*ast.VariableExpr)
+	if b.Distance != -1 {
+		t.Errorf("unmarshaled variables should be unresolved. got distance %d", b.Distance)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[{"kind":"Foo"}]`, `unknown kind "Foo"`},
+		{`[{"kind":"Ident"}]`, "Ident is not a statement"},
+		{`[{"kind":"PrintStmt","expression":{"kind":"VarStmt"}}]`, "PrintStmt.expression: VarStmt is not an expression"},
+		{`[{"kind":"ExprStmt","expression":{"kind":"BinaryExpr","operator":"@"}}]`,
+			`ExprStmt.expression: BinaryExpr.operator: unknown token "@"`},
+	}
+	for i, test := range tests {
+		if _, err := ast.Unmarshal([]byte(test.input)); err == nil || err.Error() != test.expected {
+			t.Errorf("test [%d]: expected error %q. got %v", i, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ast
+
+import "fmt"
+
+// Rewrite transforms the tree rooted at node and returns the new root. f is called
+// for each node after the children of the node are rewritten, and returns either
+// the node or its replacement. Nodes are modified in place.
+//
+// A replacement must fit where the node is: an expression for an expression, a
+// statement for a statement, and a node of the same type for other fields such as
+// the variable of an assignment. f may return nil only for a node which may be
+// absent: a statement, a method or an argument is removed from its list, and an
+// optional node, such as the else branch of an if statement or the value of a
+// return statement, is removed from its field. Rewrite panics if a replacement
+// doesn't fit, including nil for a required node.
+func Rewrite(node Node, f func(Node) Node) Node {
+	r := rewriter(f)
+
+	switch n := node.(type) {
+	case *Ident, *Literal, *SuperExpr, *ThisExpr, *VariableExpr:
+		// nothing to do
+
+	case *AssignExpr:
+		n.Left = r.variable(n.Left)
+		n.Value = r.expr(n.Value)
+	case *BinaryExpr:
+		n.Left = r.expr(n.Left)
+		n.Right = r.expr(n.Right)
+	case *CallExpr:
+		n.Callee = r.expr(n.Callee)
+		args := n.Arguments[:0]
+		for _, arg := range n.Arguments {
+			if a := r.optExpr(arg); a != nil {
+				args = append(args, a)
+			}
+		}
+		n.Arguments = args
+	case *GetExpr:
+		n.Object = r.expr(n.Object)
+	case *GroupingExpr:
+		n.Expression = r.expr(n.Expression)
+	case *InterpolationExpr:
+		for i, expr := range n.Exprs {
+			n.Exprs[i] = r.expr(expr)
+		}
+	case *LogicalExpr:
+		n.Left = r.expr(n.Left)
+		n.Right = r.expr(n.Right)
+	case *SetExpr:
+		n.Object = r.expr(n.Object)
+		n.Value = r.expr(n.Value)
+	case *UnaryExpr:
+		n.Right = r.expr(n.Right)
+
+	case *BlockStmt:
+		n.Statements = r.stmts(n.Statements)
+	case *ClassStmt:
+		if n.SuperClass.Name != "" {
+			n.SuperClass = *r.variable(&n.SuperClass)
+		}
+		methods := n.Methods[:0]
+		for _, method := range n.Methods {
+			if m := r.function(method); m != nil {
+				methods = append(methods, m)
+			}
+		}
+		n.Methods = methods
+	case *ExprStmt:
+		n.Expression = r.expr(n.Expression)
+	case *ForStmt:
+		n.Initializer = r.optStmt(n.Initializer)
+		n.Condition = r.optExpr(n.Condition)
+		n.Increment = r.optExpr(n.Increment)
+		n.Body = r.stmt(n.Body)
+	case *FunctionStmt:
+		for i, param := range n.Params {
+			n.Params[i] = r.ident(param)
+		}
+		n.Body = r.stmts(n.Body)
+	case *IfStmt:
+		n.Condition = r.expr(n.Condition)
+		n.ThenBranch = r.stmt(n.ThenBranch)
+		n.ElseBranch = r.optStmt(n.ElseBranch)
+	case *ImportStmt:
+		if n.Name != nil {
+			n.Name = r.ident(n.Name)
+		}
+		for i, name := range n.Names {
+			n.Names[i] = r.ident(name)
+		}
+	case *PrintStmt:
+		n.Expression = r.expr(n.Expression)
+	case *ReturnStmt:
+		n.Value = r.optExpr(n.Value)
+	case *VarStmt:
+		n.Name = r.ident(n.Name)
+		n.Initializer = r.optExpr(n.Initializer)
+	case *WhileStmt:
+		n.Condition = r.expr(n.Condition)
+		n.Body = r.stmt(n.Body)
+	}
+
+	return f(node)
+}
+
+type rewriter func(Node) Node
+
+func misfit(node, replacement Node) string {
+	return fmt.Sprintf("ast: Rewrite can't replace %T with %T", node, replacement)
+}
+
+// expr rewrites a required expression.
+func (r rewriter) expr(e Expr) Expr {
+	n := Rewrite(e, r)
+	if n, ok := n.(Expr); ok {
+		return n
+	}
+	panic(misfit(e, n))
+}
+
+// optExpr rewrites an expression which may be absent. It returns nil if f removes e.
+func (r rewriter) optExpr(e Expr) Expr {
+	if e == nil {
+		return nil
+	}
+	switch n := Rewrite(e, r).(type) {
+	case nil:
+		return nil
+	case Expr:
+		return n
+	default:
+		panic(misfit(e, n))
+	}
+}
+
+// stmt rewrites a required statement.
+func (r rewriter) stmt(s Stmt) Stmt {
+	n := Rewrite(s, r)
+	if n, ok := n.(Stmt); ok {
+		return n
+	}
+	panic(misfit(s, n))
+}
+
+// optStmt rewrites a statement which may be absent. It returns nil if f removes s.
+func (r rewriter) optStmt(s Stmt) Stmt {
+	if s == nil {
+		return nil
+	}
+	switch n := Rewrite(s, r).(type) {
+	case nil:
+		return nil
+	case Stmt:
+		return n
+	default:
+		panic(misfit(s, n))
+	}
+}
+
+func (r rewriter) stmts(list []Stmt) []Stmt {
+	res := list[:0]
+	for _, s := range list {
+		if n := r.optStmt(s); n != nil {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+func (r rewriter) ident(ident *Ident) *Ident {
+	n := Rewrite(ident, r)
+	if n, ok := n.(*Ident); ok && n != nil {
+		return n
+	}
+	panic(misfit(ident, n))
+}
+
+func (r rewriter) variable(v *VariableExpr) *VariableExpr {
+	n := Rewrite(v, r)
+	if n, ok := n.(*VariableExpr); ok && n != nil {
+		return n
+	}
+	panic(misfit(v, n))
+}
+
+func (r rewriter) function(fn *FunctionStmt) *FunctionStmt {
+	switch n := Rewrite(fn, r).(type) {
+	case nil:
+		return nil
+	case *FunctionStmt:
+		return n
+	default:
+		panic(misfit(fn, n))
+	}
+}
@@ -0,0 +1,131 @@
+package ast
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node in depth-first order. It starts by
+// calling v.Visit(node). Children are visited in the order of the fields of
+// node, and nil children are skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Ident, *Literal, *SuperExpr, *ThisExpr, *VariableExpr:
+		// nothing to do
+
+	case *AssignExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Value)
+	case *BinaryExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *CallExpr:
+		Walk(v, n.Callee)
+		walkExprs(v, n.Arguments)
+	case *GetExpr:
+		Walk(v, n.Object)
+	case *GroupingExpr:
+		Walk(v, n.Expression)
+	case *InterpolationExpr:
+		walkExprs(v, n.Exprs)
+	case *LogicalExpr:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *SetExpr:
+		Walk(v, n.Object)
+		Walk(v, n.Value)
+	case *UnaryExpr:
+		Walk(v, n.Right)
+
+	case *BlockStmt:
+		walkStmts(v, n.Statements)
+	case *ClassStmt:
+		if n.SuperClass.Name != "" {
+			Walk(v, &n.SuperClass)
+		}
+		for _, method := range n.Methods {
+			Walk(v, method)
+		}
+	case *ExprStmt:
+		Walk(v, n.Expression)
+	case *ForStmt:
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Increment != nil {
+			Walk(v, n.Increment)
+		}
+		Walk(v, n.Body)
+	case *FunctionStmt:
+		for _, param := range n.Params {
+			Walk(v, param)
+		}
+		walkStmts(v, n.Body)
+	case *IfStmt:
+		Walk(v, n.Condition)
+		Walk(v, n.ThenBranch)
+		if n.ElseBranch != nil {
+			Walk(v, n.ElseBranch)
+		}
+	case *ImportStmt:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		for _, name := range n.Names {
+			Walk(v, name)
+		}
+	case *PrintStmt:
+		Walk(v, n.Expression)
+	case *ReturnStmt:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *VarStmt:
+		Walk(v, n.Name)
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *WhileStmt:
+		Walk(v, n.Condition)
+		Walk(v, n.Body)
+	}
+
+	v.Visit(nil)
+}
+
+func walkExprs(v Visitor, list []Expr) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+func walkStmts(v Visitor, list []Stmt) {
+	for _, x := range list {
+		Walk(v, x)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node in depth-first order. It starts by
+// calling f(node). If f returns true, Inspect invokes f recursively for each of
+// the children of node, followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,174 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/token"
+)
+
+func num(v string) *Literal { return &Literal{Token: token.Number, Value: v} }
+
+// program returns:
+//
+//	var a = 1 + 2;
+//	class B {
+//	  m(x) { print x or this.y; super.m(); }
+//	}
+//	for (import "p" as p;;) a = -a;
+func program() []Stmt {
+	return []Stmt{
+		&VarStmt{Name: &Ident{Name: "a"}, Initializer: &BinaryExpr{Left: num("1"), Operator: token.Plus, Right: num("2")}},
+		&ClassStmt{Name: "B", Methods: []*FunctionStmt{{
+			Name:   "m",
+			Params: []*Ident{{Name: "x"}},
+			Body: []Stmt{
+				&PrintStmt{Expression: &LogicalExpr{
+					Left:     &VariableExpr{Name: "x"},
+					Operator: token.Or,
+					Right:    &GetExpr{Object: &ThisExpr{}, Name: "y"},
+				}},
+				&ExprStmt{Expression: &CallExpr{Callee: &SuperExpr{Method: "m"}}},
+			},
+		}}},
+		&ForStmt{
+			Initializer: &ImportStmt{Path: "p", Name: &Ident{Name: "p"}},
+			Body: &ExprStmt{Expression: &AssignExpr{
+				Left:  &VariableExpr{Name: "a"},
+				Value: &UnaryExpr{Operator: token.Minus, Right: &VariableExpr{Name: "a"}},
+			}},
+		},
+	}
+}
+
+func TestInspect(t *testing.T) {
+	expected := "VarStmt(Ident BinaryExpr(Literal Literal)) " +
+		"ClassStmt(FunctionStmt(Ident PrintStmt(LogicalExpr(VariableExpr GetExpr(ThisExpr))) " +
+		"ExprStmt(CallExpr(SuperExpr)))) " +
+		"ForStmt(ImportStmt(Ident) ExprStmt(AssignExpr(VariableExpr UnaryExpr(VariableExpr))))"
+	var b strings.Builder
+	for _, stmt := range program() {
+		Inspect(stmt, func(node Node) bool {
+			if node == nil {
+				b.WriteString(")")
+				return false
+			}
+			fmt.Fprintf(&b, " %s(", reflect.TypeOf(node).Elem().Name())
+			return true
+		})
+	}
+	s := strings.NewReplacer("()", "", " (", "(", "( ", "(").Replace(b.String())
+	if s = strings.TrimSpace(s); s != expected {
+		t.Errorf("nodes wrong.\nexpected=%s\ngot=     %s", expected, s)
+	}
+
+	count := 0
+	Inspect(program()[1], func(node Node) bool {
+		if node != nil {
+			count++
+		}
+		_, isFunction := node.(*FunctionStmt)
+		return !isFunction
+	})
+	if count != 2 {
+		t.Errorf("children of function are visited. got %d nodes", count)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	statements := program()
+	block := &BlockStmt{Statements: statements}
+	Rewrite(block, func(node Node) Node {
+		switch n := node.(type) {
+		case *BinaryExpr:
+			// fold 1 + 2
+			return num(n.Left.String() + n.Right.String())
+		case *PrintStmt:
+			return nil
+		case *UnaryExpr:
+			return n.Right
+		case *Ident:
+			return &Ident{Name: strings.ToUpper(n.Name)}
+		}
+		return node
+	})
+	tests := []struct {
+		node     Node
+		expected string
+	}{
+		{statements[0], "var A = 12;"},
+		{statements[1].(*ClassStmt).Methods[0].Params[0], "X"},
+		{statements[1].(*ClassStmt).Methods[0].Body[0], "super.m();"},
+		{statements[2].(*ForStmt).Body, "a = a;"},
+	}
+	for i, tt := range tests {
+		if s := fmt.Sprint(tt.node); s != tt.expected {
+			t.Errorf("test [%d]: expected %q. got %q", i, tt.expected, s)
+		}
+	}
+	if n := len(statements[1].(*ClassStmt).Methods[0].Body); n != 1 {
+		t.Errorf("print statement is not removed. got %d statements", n)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "can't replace *ast.Literal with *ast.ExprStmt") {
+			t.Errorf("expected panic for misfit replacement. got %v", r)
+		}
+	}()
+	Rewrite(program()[0], func(node Node) Node {
+		if lit, ok := node.(*Literal); ok {
+			return &ExprStmt{Expression: lit}
+		}
+		return node
+	})
+}
+
+func TestRewriteNil(t *testing.T) {
+	one, two, three := num("1"), num("2"), num("3")
+	stmt := &ExprStmt{Expression: num("1")}
+	tests := []struct {
+		node Node
+		// removed are the nodes f returns nil for.
+		removed []Node
+		// expected is the rewritten node, or the node which can't be removed.
+		expected string
+		panics   bool
+	}{
+		{&VarStmt{Name: &Ident{Name: "a"}, Initializer: two}, []Node{two}, "var a;", false},
+		{&ReturnStmt{Value: two}, []Node{two}, "return;", false},
+		{&IfStmt{Condition: one, ThenBranch: &ExprStmt{Expression: one}, ElseBranch: stmt}, []Node{stmt}, "if (1) 1;", false},
+		{&ForStmt{Initializer: stmt, Condition: three, Increment: two, Body: &BlockStmt{}}, []Node{stmt, two, three}, "while (true) {  }", false},
+		{&CallExpr{Callee: one, Arguments: []Expr{two, one, three}}, []Node{two, three}, "1(1)", false},
+		{&BinaryExpr{Left: one, Operator: token.Plus, Right: two}, []Node{two}, "*ast.Literal", true},
+		{&IfStmt{Condition: two, ThenBranch: &ExprStmt{Expression: one}}, []Node{two}, "*ast.Literal", true},
+		{&WhileStmt{Condition: one, Body: stmt}, []Node{stmt}, "*ast.ExprStmt", true},
+		{&ExprStmt{Expression: two}, []Node{two}, "*ast.Literal", true},
+	}
+	for i, tt := range tests {
+		func() {
+			defer func() {
+				r := recover()
+				expected := "can't replace " + tt.expected + " with <nil>"
+				if tt.panics && (r == nil || !strings.Contains(fmt.Sprint(r), expected)) {
+					t.Errorf("test [%d]: expected panic %q. got %v", i, expected, r)
+				}
+				if !tt.panics && r != nil {
+					t.Errorf("test [%d]: unexpected panic %v", i, r)
+				}
+			}()
+			n := Rewrite(tt.node, func(node Node) Node {
+				for _, removed := range tt.removed {
+					if node == removed {
+						return nil
+					}
+				}
+				return node
+			})
+			if s := fmt.Sprint(n); !tt.panics && s != tt.expected {
+				t.Errorf("test [%d]: expected %q. got %q", i, tt.expected, s)
+			}
+		}()
+	}
+}
@@ -0,0 +1,78 @@
+// Package bench measures the time and allocations of running Lox programs.
+//
+// The programs in testdata are the workloads of the Go benchmarks of the lexer,
+// the parser, the resolver and the interpreter, which load them with package
+// workload.
+package bench
+
+import (
+	"io/ioutil"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/interpreter"
+)
+
+// Result is the measurement of runs of a program.
+type Result struct {
+	// Times are the durations of the runs.
+	Times []time.Duration
+	// Allocs and Bytes are the numbers of heap allocations and allocated bytes
+	// per run.
+	Allocs, Bytes uint64
+}
+
+// Run executes statements n times, each in a fresh global environment. Output of
+// print statements is discarded. Run stops at the first runtime error.
+func Run(statements []ast.Stmt, n int) (*Result, error) {
+	r := &Result{}
+	interpreter.SetOutput(ioutil.Discard)
+	defer interpreter.SetOutput(nil)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < n; i++ {
+		interpreter.Reset()
+		start := time.Now()
+		err := interpreter.Execute(statements)
+		r.Times = append(r.Times, time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+	}
+	runtime.ReadMemStats(&after)
+	if n > 0 {
+		r.Allocs = (after.Mallocs - before.Mallocs) / uint64(n)
+		r.Bytes = (after.TotalAlloc - before.TotalAlloc) / uint64(n)
+	}
+	return r, nil
+}
+
+// Mean returns the mean duration of the runs.
+func (r *Result) Mean() time.Duration {
+	if len(r.Times) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, t := range r.Times {
+		sum += t
+	}
+	return sum / time.Duration(len(r.Times))
+}
+
+// Median returns the median duration of the runs.
+func (r *Result) Median() time.Duration {
+	n := len(r.Times)
+	if n == 0 {
+		return 0
+	}
+	times := append([]time.Duration(nil), r.Times...)
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	if n%2 == 0 {
+		return (times[n/2-1] + times[n/2]) / 2
+	}
+	return times[n/2]
+}
@@ -0,0 +1,55 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func TestRun(t *testing.T) {
+	statements, err := parser.ParseStmts("var s = \"\"; for (var i = 0; i < 10; i = i + 1) s = s + i; print s;")
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	r, err := Run(statements, 3)
+	if err != nil {
+		t.Fatalf("run failed. error: %s", err)
+	}
+	if len(r.Times) != 3 {
+		t.Fatalf("expected 3 runs. got %d", len(r.Times))
+	}
+	if r.Allocs == 0 || r.Bytes == 0 {
+		t.Errorf("expected allocations. got %d allocs, %d bytes", r.Allocs, r.Bytes)
+	}
+
+	statements, err = parser.ParseStmts("print 1; print -nil;")
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	if _, err := Run(statements, 3); err == nil || err.Error() != "1:10 Operand must be a number." {
+		t.Errorf("expected runtime error. got %v", err)
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		times  []time.Duration
+		mean   time.Duration
+		median time.Duration
+	}{
+		{nil, 0, 0},
+		{[]time.Duration{5}, 5, 5},
+		{[]time.Duration{9, 1, 2}, 4, 2},
+		{[]time.Duration{8, 1, 4, 3}, 4, 3},
+	}
+	for i, tt := range tests {
+		r := &Result{Times: tt.times}
+		if mean := r.Mean(); mean != tt.mean {
+			t.Errorf("test [%d]: expected mean %v. got %v", i, tt.mean, mean)
+		}
+		if median := r.Median(); median != tt.median {
+			t.Errorf("test [%d]: expected median %v. got %v", i, tt.median, median)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package workload loads the Lox programs used as workloads of the Go
+// benchmarks. It imports no other package of the module, so the benchmarks of
+// the lexer, the parser, the resolver and the interpreter can all use it.
+package workload
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Workload is a benchmark program.
+type Workload struct {
+	// Name is the file name without the .lox extension.
+	Name string
+	Src  string
+}
+
+// Load returns the programs in the .lox files of dir, sorted by name.
+func Load(dir string) ([]Workload, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.lox"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no benchmark programs in %s", dir)
+	}
+	var res []Workload
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Workload{strings.TrimSuffix(filepath.Base(file), ".lox"), string(src)})
+	}
+	return res, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func astMain(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox ast [-json] file.lox")
+		fmt.Fprintln(os.Stderr, "Prints the syntax tree of the file.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	src, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	statements, err := parser.New(lexer.New(string(src))).Parse()
+	if err != nil {
+		return 1
+	}
+	if !*asJSON {
+		for _, stmt := range statements {
+			fmt.Println(stmt)
+		}
+		return 0
+	}
+	data, err := ast.Marshal(statements)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var buf bytes.Buffer
+	json.Indent(&buf, data, "", "  ")
+	buf.WriteByte('\n')
+	os.Stdout.Write(buf.Bytes())
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ziyoung/lox-go/bench"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func benchMain(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	n := flags.Int("n", 10, "run each file `n` times")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox bench [-n runs] file.lox ...")
+		fmt.Fprintln(os.Stderr, "Runs each file n times and prints the mean and median time and the allocations per run.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 || *n < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	code := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "file\truns\tmean\tmedian\tallocs/run\tbytes/run\t")
+	for _, name := range flags.Args() {
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		statements, err := parser.ParseStmts(string(src))
+		if err != nil {
+			// The parser has reported the error with its position.
+			fmt.Fprintf(os.Stderr, "%s: parse error\n", name)
+			code = 1
+			continue
+		}
+		if err := setFile(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		r, err := bench.Run(statements, *n)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%v\t%v\t%d\t%d\t\n", name, len(r.Times), r.Mean(), r.Median(), r.Allocs, r.Bytes)
+	}
+	w.Flush()
+	return code
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ziyoung/lox-go/dap"
+)
+
+func dapMain(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: lox dap")
+		fmt.Fprintln(os.Stderr, "Debug adapter speaking DAP over stdin and stdout.")
+		return 2
+	}
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/debug"
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+// debugCommand is a command of the terminal debugger.
+type debugCommand struct {
+	names []string
+	args  string
+	help  string
+	run   func(s *debugSession, arg string)
+}
+
+var debugCommands []*debugCommand
+
+func init() {
+	debugCommands = []*debugCommand{
+		{[]string{"break", "b"}, "[file:]line [if cond]", "set a breakpoint, optionally conditional", (*debugSession).breakpoint},
+		{[]string{"delete", "d"}, "[file:]line", "delete the breakpoint at line", (*debugSession).delete},
+		{[]string{"watch"}, "expr", "stop when the value of expr changes", (*debugSession).watch},
+		{[]string{"info", "i"}, "", "list breakpoints and watches", (*debugSession).info},
+		{[]string{"run", "r"}, "", "start the program", (*debugSession).run},
+		{[]string{"continue", "c"}, "", "continue until a breakpoint or watch", resume(func(d *debug.Debugger) error { return d.Continue() })},
+		{[]string{"next", "n"}, "", "step over calls to the next statement", resume(func(d *debug.Debugger) error { return d.StepOver() })},
+		{[]string{"step", "s"}, "", "step into calls to the next statement", resume(func(d *debug.Debugger) error { return d.StepIn() })},
+		{[]string{"finish", "fin"}, "", "run until the current function returns", resume(func(d *debug.Debugger) error { return d.StepOut() })},
+		{[]string{"print", "p"}, "expr", "evaluate expr in the selected frame", (*debugSession).print},
+		{[]string{"locals"}, "", "show variables of the selected frame", (*debugSession).locals},
+		{[]string{"backtrace", "bt"}, "", "show the call stack", (*debugSession).backtrace},
+		{[]string{"frame", "f"}, "n", "select frame n of the call stack", (*debugSession).selectFrame},
+		{[]string{"list", "l"}, "", "show source around the current line", (*debugSession).list},
+		{[]string{"help", "h"}, "", "show this help", (*debugSession).help},
+		{[]string{"quit", "q"}, "", "exit the debugger", nil},
+	}
+}
+
+func lookupDebugCommand(name string) *debugCommand {
+	for _, cmd := range debugCommands {
+		for _, n := range cmd.names {
+			if n == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// debugSession keeps state of the terminal debugger.
+type debugSession struct {
+	out io.Writer
+	// file is the program as named by the user, and main is its absolute name.
+	file, main string
+	// sources caches lines of files by their absolute names.
+	sources    map[string][]string
+	statements []ast.Stmt
+	dbg        *debug.Debugger
+
+	// events receives a debug.Stop when the program pauses, or an error (possibly nil) when it ends.
+	events  chan interface{}
+	running bool
+	// frame is the selected frame, 0 for the innermost one.
+	frame int
+	// current and line are the current file and line, used by list.
+	current string
+	line    int
+}
+
+func debugMain(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lox debug file.lox")
+		return 2
+	}
+	src, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	statements, err := parser.ParseStmts(string(src))
+	if err != nil {
+		return 1
+	}
+	main, err := filepath.Abs(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	s := &debugSession{
+		out:        os.Stdout,
+		file:       args[0],
+		main:       main,
+		sources:    map[string][]string{main: strings.Split(string(src), "\n")},
+		statements: statements,
+		current:    main,
+		dbg:        debug.New(),
+		events:     make(chan interface{}),
+	}
+	s.dbg.OnStop = func(stop debug.Stop) {
+		s.events <- stop
+	}
+	fmt.Fprintf(s.out, "Debugging %s. Type \"help\" for help.\n", s.file)
+	s.loop(os.Stdin)
+	return 0
+}
+
+// loop reads and runs commands until quit or the end of input.
+func (s *debugSession) loop(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	last := ""
+	for {
+		fmt.Fprint(s.out, "(lox) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			// an empty line repeats the last command like gdb.
+			line = last
+		}
+		if line == "" {
+			continue
+		}
+		last = line
+		name, arg := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			name, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		cmd := lookupDebugCommand(name)
+		if cmd == nil {
+			fmt.Fprintf(s.out, "unknown command %s. Type \"help\" for help.\n", name)
+			continue
+		}
+		if cmd.run == nil {
+			break
+		}
+		if cmd.args != "" && !strings.HasPrefix(cmd.args, "[") && arg == "" {
+			fmt.Fprintf(s.out, "usage: %s %s\n", cmd.names[0], cmd.args)
+			continue
+		}
+		cmd.run(s, arg)
+	}
+	if s.running {
+		s.dbg.Terminate()
+		<-s.events
+	}
+}
+
+func (s *debugSession) help(arg string) {
+	for _, cmd := range debugCommands {
+		usage := strings.Join(cmd.names, ", ")
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(s.out, "  %-32s %s\n", usage, cmd.help)
+	}
+}
+
+// location parses spec as [file:]line, where file defaults to the program. Files
+// are looked up like imports, relative to the directory of the program, and
+// relative to the current directory. It tells the user if spec is invalid.
+func (s *debugSession) location(spec string) (string, int, bool) {
+	file := s.main
+	if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+		name := spec[:i]
+		if file = s.lookup(name); file == "" {
+			fmt.Fprintf(s.out, "no source file named %s.\n", name)
+			return "", 0, false
+		}
+		spec = spec[i+1:]
+	}
+	line, err := strconv.Atoi(spec)
+	if err != nil || line < 1 || line > len(s.source(file)) {
+		fmt.Fprintf(s.out, "invalid line %q.\n", spec)
+		return "", 0, false
+	}
+	return file, line, true
+}
+
+// lookup returns the absolute name of the source file named name, or "" if there is none.
+func (s *debugSession) lookup(name string) string {
+	if name == s.file || name == filepath.Base(s.file) {
+		return s.main
+	}
+	if filepath.Ext(name) != ".lox" {
+		name += ".lox"
+	}
+	for _, file := range []string{filepath.Join(filepath.Dir(s.main), name), name} {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(file); err == nil {
+				return abs
+			}
+		}
+	}
+	return ""
+}
+
+// source returns lines of file, which is read the first time.
+func (s *debugSession) source(file string) []string {
+	lines, ok := s.sources[file]
+	if !ok {
+		if src, err := ioutil.ReadFile(file); err == nil {
+			lines = strings.Split(string(src), "\n")
+		}
+		s.sources[file] = lines
+	}
+	return lines
+}
+
+// name returns file as it is shown to the user: the program as named by the user,
+// and other files relative to the current directory if possible.
+func (s *debugSession) name(file string) string {
+	if file == s.main {
+		return s.file
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return file
+}
+
+func (s *debugSession) breakpoint(arg string) {
+	spec, cond := arg, ""
+	if i := strings.Index(arg, " if "); i >= 0 {
+		spec, cond = strings.TrimSpace(arg[:i]), strings.TrimSpace(arg[i+4:])
+	}
+	file, line, ok := s.location(spec)
+	if !ok {
+		return
+	}
+	if _, err := s.dbg.SetBreakpoint(file, line, cond); err != nil {
+		fmt.Fprintf(s.out, "invalid condition: %s\n", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Breakpoint at %s:%d.\n", s.name(file), line)
+}
+
+func (s *debugSession) delete(arg string) {
+	file, line, ok := s.location(arg)
+	if ok && !s.dbg.ClearBreakpoint(file, line) {
+		fmt.Fprintf(s.out, "no breakpoint at %s:%d.\n", s.name(file), line)
+	}
+}
+
+func (s *debugSession) watch(arg string) {
+	if _, err := s.dbg.AddWatch(arg); err != nil {
+		fmt.Fprintf(s.out, "invalid expression: %s\n", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Watching %s.\n", arg)
+}
+
+func (s *debugSession) info(arg string) {
+	bps, watches := s.dbg.Breakpoints(), s.dbg.Watches()
+	if len(bps) == 0 && len(watches) == 0 {
+		fmt.Fprintln(s.out, "No breakpoints or watches.")
+	}
+	for _, bp := range bps {
+		fmt.Fprintf(s.out, "breakpoint %s:%d", s.name(bp.File), bp.Line)
+		if bp.Condition != "" {
+			fmt.Fprintf(s.out, " if %s", bp.Condition)
+		}
+		fmt.Fprintln(s.out)
+	}
+	for _, w := range watches {
+		fmt.Fprintf(s.out, "watch %s\n", w.Expr)
+	}
+}
+
+func (s *debugSession) run(arg string) {
+	if s.running {
+		fmt.Fprintln(s.out, "The program is already running.")
+		return
+	}
+	if err := setFile(s.file); err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	s.running = true
+	interpreter.Reset()
+	go func() {
+		s.events <- s.dbg.Run(s.statements, false)
+	}()
+	s.wait()
+}
+
+// resume returns a command resuming the paused program with fn.
+func resume(fn func(d *debug.Debugger) error) func(s *debugSession, arg string) {
+	return func(s *debugSession, arg string) {
+		if !s.running {
+			fmt.Fprintln(s.out, "The program is not being run.")
+			return
+		}
+		if err := fn(s.dbg); err != nil {
+			fmt.Fprintln(s.out, err)
+			return
+		}
+		s.wait()
+	}
+}
+
+// wait waits until the program pauses or ends.
+func (s *debugSession) wait() {
+	switch e := (<-s.events).(type) {
+	case debug.Stop:
+		s.frame, s.current, s.line = 0, e.File, e.Pos.Line
+		switch e.Reason {
+		case debug.ReasonBreakpoint:
+			fmt.Fprintf(s.out, "Breakpoint at %s:%d\n", s.name(e.File), e.Pos.Line)
+		case debug.ReasonWatch:
+			fmt.Fprintf(s.out, "Watch %s: %s -> %s\n", e.Watch.Expr, e.Old, e.New)
+		}
+		s.printLine(e.File, e.Pos.Line, "")
+	case error:
+		s.running = false
+		fmt.Fprintf(s.out, "Program exited with error: %s\n", e)
+	default:
+		s.running = false
+		fmt.Fprintln(s.out, "Program exited normally.")
+	}
+}
+
+func (s *debugSession) printLine(file string, line int, marker string) {
+	if lines := s.source(file); line >= 1 && line <= len(lines) {
+		fmt.Fprintf(s.out, "%s%d\t%s\n", marker, line, lines[line-1])
+	}
+}
+
+// paused reports whether the program is paused, and tells the user if it isn't.
+func (s *debugSession) paused() bool {
+	if !s.running || !s.dbg.Paused() {
+		fmt.Fprintln(s.out, "The program is not being run.")
+		return false
+	}
+	return true
+}
+
+func (s *debugSession) print(arg string) {
+	if !s.paused() {
+		return
+	}
+	v, err := s.dbg.Evaluate(arg, s.frame)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	fmt.Fprintf(s.out, "%s = %s\n", arg, v)
+}
+
+func (s *debugSession) locals(arg string) {
+	if !s.paused() {
+		return
+	}
+	scopes, err := s.dbg.Scopes(s.frame)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	empty := true
+	for _, scope := range scopes {
+		if scope.Name == "Globals" {
+			break
+		}
+		for _, name := range debug.Names(scope.Env) {
+			fmt.Fprintf(s.out, "%s = %s\n", name, scope.Env.Values[name])
+			empty = false
+		}
+	}
+	if empty {
+		fmt.Fprintln(s.out, "No locals.")
+	}
+}
+
+func (s *debugSession) backtrace(arg string) {
+	if !s.paused() {
+		return
+	}
+	frames, _ := s.dbg.Frames()
+	for i, f := range frames {
+		marker := "  "
+		if i == s.frame {
+			marker = "* "
+		}
+		fmt.Fprintf(s.out, "%s#%d %s at %s:%d\n", marker, i, f.Function, s.name(f.File), f.Pos.Line)
+	}
+}
+
+func (s *debugSession) selectFrame(arg string) {
+	if !s.paused() {
+		return
+	}
+	frames, _ := s.dbg.Frames()
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 || n >= len(frames) {
+		fmt.Fprintf(s.out, "no frame %s.\n", arg)
+		return
+	}
+	f := frames[n]
+	s.frame, s.current, s.line = n, f.File, f.Pos.Line
+	fmt.Fprintf(s.out, "#%d %s at %s:%d\n", n, f.Function, s.name(f.File), f.Pos.Line)
+	s.printLine(f.File, f.Pos.Line, "")
+}
+
+func (s *debugSession) list(arg string) {
+	line := s.line
+	if line == 0 {
+		line = 1
+	}
+	for i := line - 5; i <= line+5; i++ {
+		marker := "  "
+		if i == s.line {
+			marker = "=>"
+		}
+		s.printLine(s.current, i, marker)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around changes.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff between a and b, or "" if they are equal.
+func unifiedDiff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s (formatted)\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// find the hunk containing changes close to each other.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		aStart, bStart := lineNumbers(ops[:start])
+		aLen, bLen := lineNumbers(ops[start:end])
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLen, bStart+1, bLen)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// lineNumbers counts lines of a and b covered by ops.
+func lineNumbers(ops []diffOp) (a, b int) {
+	for _, op := range ops {
+		if op.kind != '+' {
+			a++
+		}
+		if op.kind != '-' {
+			b++
+		}
+	}
+	return a, b
+}
+
+// diffLines computes the shortest edit script from a to b. Common leading and
+// trailing lines are matched first, and the rest is diffed with the Myers algorithm.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:pre] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myers returns the shortest edit script from a to b. It takes O((n+m)d) time and
+// O(d²) memory, where d is the number of inserted and deleted lines.
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m
+	// v[offset+k] is the furthest x reached on diagonal k = x - y.
+	v := make([]int, 2*offset+2)
+	// trace[d] holds v[offset-d:offset+d+1] after d edits.
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		if v[offset+n-m] >= n && n-m >= -d && n-m <= d {
+			break
+		}
+	}
+
+	// walk back from (n, m) to (0, 0), collecting operations in reverse.
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		startX := prevX
+		if prevK == k-1 {
+			startX++
+		}
+		for x > startX {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if prevK == k+1 {
+			ops = append(ops, diffOp{'+', b[prevY]})
+		} else {
+			ops = append(ops, diffOp{'-', a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 {
+		x--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
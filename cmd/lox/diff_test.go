@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{"a\nb\nc\n", "a\nx\nb\nc\n", "@@ -1,3 +1,4 @@\n a\n+x\n b\n c\n"},
+		{"a\nb\nc\n", "a\nc\n", "@@ -1,3 +1,2 @@\n a\n-b\n c\n"},
+		{"a\nb\nc\n", "a\nx\nc\n", "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"", "a\n", "@@ -1,0 +1,1 @@\n+a\n"},
+		{"a\n", "", "@@ -1,1 +1,0 @@\n-a\n"},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+	for i, tt := range tests {
+		expected := tt.expected
+		if expected != "" {
+			expected = "--- f\n+++ f (formatted)\n" + expected
+		}
+		if got := unifiedDiff("f", tt.a, tt.b); got != expected {
+			t.Errorf("test [%d]: expected diff\n%s\ngot\n%s", i, expected, got)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abcabba", "cbabac", 5},
+		{"abcdef", "", 6},
+		{"", "xyz", 3},
+		{"axbxc", "aybyc", 4},
+		{"abcd", "dcba", 6},
+	}
+	for i, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		ops := diffLines(a, b)
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, "") != tt.a || strings.Join(gotB, "") != tt.b {
+			t.Errorf("test [%d]: edit script doesn't turn %q into %q", i, tt.a, tt.b)
+		}
+		if edits != tt.edits {
+			t.Errorf("test [%d]: expected %d edits. got %d", i, tt.edits, edits)
+		}
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	a := make([]string, 100000)
+	for i := range a {
+		a[i] = strings.Repeat("x", i%7)
+	}
+	b := append(append([]string(nil), a[:50000]...), append([]string{"new"}, a[50001:]...)...)
+	ops := diffLines(a, b)
+	if len(ops) != len(a)+1 {
+		t.Errorf("expected %d operations. got %d", len(a)+1, len(ops))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziyoung/lox-go/format"
+)
+
+func fmtMain(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	diff := flags.Bool("d", false, "print diffs instead of rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox fmt [-d] [path ...]")
+		fmt.Fprintln(os.Stderr, "Without paths, source is read from stdin and written to stdout.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		res, err := format.Source(src)
+		if err != nil {
+			return 1
+		}
+		if *diff {
+			os.Stdout.WriteString(unifiedDiff("<standard input>", string(src), string(res)))
+		} else {
+			os.Stdout.Write(res)
+		}
+		return 0
+	}
+
+	files, err := loxFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code := 0
+	for _, file := range files {
+		if err := formatFile(file, *diff); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// errParse is returned by formatFile for a file the parser has already reported
+// an error for.
+var errParse = errors.New("parse error")
+
+func formatFile(file string, diff bool) error {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	res, err := format.Source(src)
+	if err != nil {
+		return errParse
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+	if diff {
+		os.Stdout.WriteString(unifiedDiff(file, string(src), string(res)))
+		return nil
+	}
+	return ioutil.WriteFile(file, res, 0644)
+}
+
+// loxFiles expands directories in paths to the .lox files they contain.
+func loxFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(file, ".lox") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ziyoung/lox-go/lint"
+)
+
+func lintMain(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox lint path ...")
+		fmt.Fprintln(os.Stderr, "Exit status is 1 if any warning is reported.")
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	files, err := loxFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code := 0
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		warnings, err := lint.Source(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			code = 1
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Printf("%s:%s\n", file, w)
+			code = 1
+		}
+	}
+	return code
+}
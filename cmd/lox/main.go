@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/ziyoung/lox-go/cmd/lox/repl"
 	"github.com/ziyoung/lox-go/interpreter"
@@ -11,8 +12,28 @@ import (
 	"github.com/ziyoung/lox-go/parser"
 )
 
+// commands are subcommands of lox. Each command receives arguments after its name
+// and returns the exit code.
+var commands = map[string]func(args []string) int{
+	"ast":    astMain,
+	"bench":  benchMain,
+	"dap":    dapMain,
+	"debug":  debugMain,
+	"fmt":    fmtMain,
+	"lint":   lintMain,
+	"lsp":    lspMain,
+	"run":    runMain,
+	"test":   testMain,
+	"tokens": tokensMain,
+	"vendor": vendorMain,
+}
+
 func main() {
+	interpreter.SetPath(filepath.SplitList(os.Getenv("LOX_PATH")))
 	if len(os.Args) >= 2 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
 		name := os.Args[1]
 		b, err := ioutil.ReadFile(name)
 		if err != nil {
@@ -21,6 +42,10 @@ func main() {
 		l := lexer.New(string(b))
 		p := parser.New(l)
 		if statements, err := p.Parse(); err == nil && len(statements) != 0 {
+			if err := setFile(name); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 			interpreter.Interpret(statements)
 		}
 		return
@@ -28,6 +53,6 @@ func main() {
 
 	fmt.Fprintln(os.Stdout, "Lox programing language.")
 	fmt.Fprintln(os.Stdout, "Feel free to type commands.")
-	fmt.Fprintln(os.Stdout, "Type \"exit\" to exit.")
+	fmt.Fprintln(os.Stdout, "Type \":help\" for help, \"exit\" to exit.")
 	repl.Start(os.Stdin, os.Stdout)
 }
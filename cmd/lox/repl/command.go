@@ -0,0 +1,197 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/token"
+)
+
+// command is a REPL meta-command starting with ':'.
+type command struct {
+	name string
+	args string
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"help", "", "show this help", (*session).help},
+		{"env", "", "list global bindings with their types", (*session).env},
+		{"type", "expr", "show type of an expression", (*session).typeOf},
+		{"ast", "input", "show syntax tree of an expression or statements", (*session).ast},
+		{"tokens", "input", "show tokens of input", (*session).tokens},
+		{"load", "file", "run a file in the current session", (*session).load},
+		{"save", "file", "save inputs of the session into a file", (*session).save},
+		{"reset", "", "discard all bindings and start a fresh session", (*session).reset},
+		{"time", "input", "run input and show elapsed time", (*session).time},
+	}
+}
+
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// session keeps state of a REPL session.
+type session struct {
+	out io.Writer
+	// inputs are sources which have been run successfully.
+	inputs []string
+}
+
+// exec runs a meta-command line like ":type 1 + 2".
+func (s *session) exec(line string) {
+	name, arg := line[1:], ""
+	if i := strings.IndexAny(name, " \t"); i >= 0 {
+		name, arg = name[:i], strings.TrimSpace(name[i+1:])
+	}
+	cmd := lookupCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(s.out, "unknown command :%s. Type :help for help.\n", name)
+		return
+	}
+	if cmd.args != "" && arg == "" {
+		fmt.Fprintf(s.out, "usage: :%s %s\n", cmd.name, cmd.args)
+		return
+	}
+	cmd.run(s, arg)
+}
+
+// run parses and interprets input, and records it in the session if it runs
+// without errors.
+func (s *session) run(input string) {
+	statements, err := parser.ParseStmts(input)
+	if err != nil || len(statements) == 0 {
+		return
+	}
+	if interpreter.Interpret(statements) == nil {
+		s.inputs = append(s.inputs, input)
+	}
+}
+
+func (s *session) help(arg string) {
+	fmt.Fprintln(s.out, "Commands:")
+	for _, cmd := range commands {
+		usage := ":" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(s.out, "  %-16s %s\n", usage, cmd.help)
+	}
+	fmt.Fprintf(s.out, "  %-16s %s\n", "exit", "exit the REPL")
+}
+
+func (s *session) env(arg string) {
+	values := globalValues()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := values[name]
+		fmt.Fprintf(s.out, "%-16s %-10s %s\n", name, v.Type(), v)
+	}
+}
+
+func (s *session) typeOf(arg string) {
+	expr, err := parser.ParseExpr(arg)
+	if err != nil {
+		fmt.Fprintln(s.out, err.Error())
+		return
+	}
+	v, err := interpreter.Evaluate(expr)
+	if err != nil {
+		fmt.Fprintln(s.out, err.Error())
+		return
+	}
+	fmt.Fprintln(s.out, v.Type())
+}
+
+func (s *session) ast(arg string) {
+	if !isStatements(arg) {
+		expr, err := parser.ParseExpr(arg)
+		if err != nil {
+			fmt.Fprintln(s.out, err.Error())
+			return
+		}
+		fmt.Fprintln(s.out, expr)
+		return
+	}
+	statements, err := parser.ParseStmts(arg)
+	if err != nil {
+		fmt.Fprintln(s.out, err.Error())
+		return
+	}
+	for _, stmt := range statements {
+		fmt.Fprintln(s.out, stmt)
+	}
+}
+
+func (s *session) tokens(arg string) {
+	l := lexer.New(arg)
+	for {
+		tok, lit := l.NextToken()
+		if tok == token.EOF {
+			return
+		}
+		fmt.Fprintf(s.out, "%-12s %q\n", tok, lit)
+	}
+}
+
+func (s *session) load(arg string) {
+	b, err := ioutil.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintln(s.out, err.Error())
+		return
+	}
+	s.run(string(b))
+}
+
+func (s *session) save(arg string) {
+	content := strings.Join(s.inputs, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := ioutil.WriteFile(arg, []byte(content), 0644); err != nil {
+		fmt.Fprintln(s.out, err.Error())
+		return
+	}
+	fmt.Fprintf(s.out, "saved %d inputs to %s.\n", len(s.inputs), arg)
+}
+
+func (s *session) reset(arg string) {
+	interpreter.Reset()
+	s.inputs = nil
+	fmt.Fprintln(s.out, "environment is reset.")
+}
+
+func (s *session) time(arg string) {
+	if !isStatements(arg) {
+		arg += ";"
+	}
+	start := time.Now()
+	s.run(arg)
+	fmt.Fprintf(s.out, "elapsed: %s\n", time.Since(start))
+}
+
+// isStatements reports whether input consists of statements rather than a single expression.
+func isStatements(input string) bool {
+	input = strings.TrimSpace(input)
+	return strings.HasSuffix(input, ";") || strings.HasSuffix(input, "}")
+}
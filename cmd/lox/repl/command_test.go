@@ -0,0 +1,108 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+func TestSessionCommands(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{":type 1 + 2", "number\n"},
+		{`:type "a" + 1`, "string\n"},
+		{":type 1 +", "1:4 Expect expression.\n"},
+		{":type -nil", "Operand must be a number.\n"},
+		{":ast 1 + 2 * 3", "(1 + (2 * 3))\n"},
+		{":ast var a = 1; print a;", "var a = 1;\nprint a;\n"},
+		{":ast (1", "1:3 Expect ) after expression.\n"},
+		{":ast var = 1;", "1:5 Expect variable name.\n"},
+		{":tokens a = 1", "identifier   \"a\"\n=            \"=\"\nnumber       \"1\"\n"},
+		{":type", "usage: :type expr\n"},
+		{":nope", "unknown command :nope. Type :help for help.\n"},
+	}
+	for i, tt := range tests {
+		interpreter.Reset()
+		var buf bytes.Buffer
+		s := &session{out: &buf}
+		s.exec(tt.line)
+		if buf.String() != tt.expected {
+			t.Errorf("test [%d]: %s expected output %q. got %q", i, tt.line, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestSessionSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lox-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "session.lox")
+
+	interpreter.Reset()
+	interpreter.SetOutput(ioutil.Discard)
+	defer interpreter.SetOutput(nil)
+	var buf bytes.Buffer
+	s := &session{out: &buf}
+	for _, input := range []string{"var a = 1;", "print -nil;", "var b = ;", "print a + 1;"} {
+		s.run(input)
+	}
+	s.exec(":save " + file)
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "var a = 1;\nprint a + 1;\n"; string(b) != expected {
+		t.Errorf("expected saved inputs %q. got %q", expected, string(b))
+	}
+	if expected := "saved 2 inputs to " + file + ".\n"; buf.String() != expected {
+		t.Errorf("expected output %q. got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	s.exec(":reset")
+	if _, ok := interpreter.Globals().Get("a"); ok || len(s.inputs) != 0 {
+		t.Errorf("reset should discard bindings and inputs")
+	}
+	s.exec(":load " + file)
+	if v, ok := interpreter.Globals().Get("a"); !ok || v.String() != "1" {
+		t.Errorf("load should define a. got %v", v)
+	}
+	if len(s.inputs) != 1 {
+		t.Errorf("expected the loaded file to be recorded. got %q", s.inputs)
+	}
+	buf.Reset()
+	s.exec(":env")
+	if expected := "a                number     1\n"; buf.String() != expected {
+		t.Errorf("expected env %q. got %q", expected, buf.String())
+	}
+}
+
+func TestSessionDefined(t *testing.T) {
+	interpreter.Reset()
+	defer interpreter.Reset()
+	interpreter.Define("hostFn", &valuer.NativeFunction{
+		Name: "hostFn",
+		Fn: func(args []valuer.Valuer) (valuer.Valuer, error) {
+			return interpreter.Nil, nil
+		},
+	})
+	if _, candidates := complete([]rune("host"), 4); len(candidates) != 1 || candidates[0] != "hostFn" {
+		t.Errorf("expected completion of defined hostFn. got %q", candidates)
+	}
+	var buf bytes.Buffer
+	s := &session{out: &buf}
+	s.exec(":env")
+	if !strings.Contains(buf.String(), "hostFn") {
+		t.Errorf("expected env to list defined hostFn. got %q", buf.String())
+	}
+}
@@ -0,0 +1,144 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// completer returns candidates completing the word which ends at pos of line,
+// along with the word itself.
+type completer func(line []rune, pos int) (word string, candidates []string)
+
+// complete completes keywords and global names, members after "obj.",
+// and meta-commands at the start of line.
+func complete(line []rune, pos int) (string, []string) {
+	start := pos
+	for start > 0 && isIdentChar(line[start-1]) {
+		start--
+	}
+	word := string(line[start:pos])
+
+	var names []string
+	switch {
+	case start > 0 && line[start-1] == '.':
+		names = members(objectPath(line[:start-1]))
+	case start == 1 && line[0] == ':':
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+	default:
+		names = token.Keywords()
+		for name := range globalValues() {
+			names = append(names, name)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return word, candidates
+}
+
+// globalValues returns values of the global environment and the environments
+// enclosing it, such as host values added by interpreter.Define.
+func globalValues() map[string]valuer.Valuer {
+	values := make(map[string]valuer.Valuer)
+	for env := interpreter.Globals(); env != nil; env = env.Enclosing {
+		for name, v := range env.Values {
+			if _, ok := values[name]; !ok {
+				values[name] = v
+			}
+		}
+	}
+	return values
+}
+
+// objectPath returns names of a chain like "a.b.c" which ends at the end of line.
+func objectPath(line []rune) []string {
+	end := len(line)
+	var path []string
+	for {
+		start := end
+		for start > 0 && isIdentChar(line[start-1]) {
+			start--
+		}
+		if start == end {
+			return nil
+		}
+		path = append([]string{string(line[start:end])}, path...)
+		if start == 0 || line[start-1] != '.' {
+			return path
+		}
+		end = start - 1
+	}
+}
+
+// members returns fields and methods of the value that path refers to.
+// Path is looked up in the global environment without evaluating any code.
+func members(path []string) []string {
+	if len(path) == 0 {
+		return nil
+	}
+	v, ok := interpreter.Globals().Get(path[0])
+	for _, name := range path[1:] {
+		if !ok {
+			return nil
+		}
+		v, ok = member(v, name)
+	}
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	switch o := v.(type) {
+	case *valuer.Instance:
+		names = append(names, o.FieldNames()...)
+		for name := range o.Klass.Mehtods {
+			names = append(names, name)
+		}
+	case *valuer.GoObject:
+		names = append(o.Fields(), o.Methods()...)
+	}
+	return names
+}
+
+func member(v valuer.Valuer, name string) (valuer.Valuer, bool) {
+	switch o := v.(type) {
+	case *valuer.Instance:
+		return o.Get(name)
+	case *valuer.GoObject:
+		v, err := o.Get(name)
+		return v, err == nil
+	}
+	return nil, false
+}
+
+func isIdentChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsNumber(ch) || ch == '_'
+}
+
+// commonPrefix returns the longest common prefix of words.
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
@@ -0,0 +1,91 @@
+package repl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func TestComplete(t *testing.T) {
+	interpreter.Reset()
+	statements, err := parser.ParseStmts(`
+class Point {
+  init(x, y) { this.x = x; this.y = y; }
+  length() { return 0; }
+}
+var origin = Point(0, 0);
+origin.next = Point(1, 1);
+var printed = 1;
+fun prize() {}`)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	if err := interpreter.Execute(statements); err != nil {
+		t.Fatalf("execute failed. error: %s", err)
+	}
+
+	tests := []struct {
+		line       string
+		word       string
+		candidates []string
+	}{
+		{"pri", "pri", []string{"print", "printed", "prize"}},
+		{"var a = orig", "orig", []string{"origin"}},
+		{"Po", "Po", []string{"Point"}},
+		{"wh", "wh", []string{"while"}},
+		{"origin.", "", []string{"init", "length", "next", "x", "y"}},
+		{"print origin.l", "l", []string{"length"}},
+		{"origin.next.", "", []string{"init", "length", "x", "y"}},
+		{"origin.missing.", "", nil},
+		{"printed.", "", nil},
+		{"(origin).", "", nil},
+		{":lo", "lo", []string{"load"}},
+		{":t", "t", []string{"time", "tokens", "type"}},
+		{"zzz", "zzz", nil},
+	}
+	for i, tt := range tests {
+		line := []rune(tt.line)
+		word, candidates := complete(line, len(line))
+		if word != tt.word || !reflect.DeepEqual(candidates, tt.candidates) {
+			t.Errorf("test [%d]: %q expected %q %q. got %q %q", i, tt.line, tt.word, tt.candidates, word, candidates)
+		}
+	}
+}
+
+func TestObjectPath(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"a", []string{"a"}},
+		{"print a.b.c", []string{"a", "b", "c"}},
+		{"f(a).b", nil},
+		{"a.", nil},
+		{"", nil},
+	}
+	for i, tt := range tests {
+		if got := objectPath([]rune(tt.line)); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("test [%d]: objectPath(%q) expected %q. got %q", i, tt.line, tt.expected, got)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		words    []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"print"}, "print"},
+		{[]string{"print", "printed", "prize"}, "pri"},
+		{[]string{"a", "b"}, ""},
+		{[]string{"变量一", "变量二"}, "变量"},
+	}
+	for i, tt := range tests {
+		if got := commonPrefix(tt.words); got != tt.expected {
+			t.Errorf("test [%d]: commonPrefix(%q) expected %q. got %q", i, tt.words, tt.expected, got)
+		}
+	}
+}
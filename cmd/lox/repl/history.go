@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is the max number of entries loaded from the history file.
+const maxHistory = 1000
+
+// history keeps entered lines and persists them into a file.
+type history struct {
+	entries []string
+	file    string
+}
+
+// historyFile returns path of the history file.
+// It can be specified by LOX_HISTORY environment variable.
+func historyFile() string {
+	if file := os.Getenv("LOX_HISTORY"); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lox_history")
+}
+
+func loadHistory(file string) *history {
+	h := &history{file: file}
+	if file == "" {
+		return h
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	return h
+}
+
+// add appends line to history. Empty lines and repeated lines are ignored.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if h.file == "" {
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
@@ -0,0 +1,68 @@
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lox-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "history")
+
+	h := loadHistory(file)
+	if len(h.entries) != 0 {
+		t.Fatalf("expected empty history. got %q", h.entries)
+	}
+	for _, line := range []string{"var a = 1;", "", "  ", "print a;", "print a;", "var a = 1;"} {
+		h.add(line)
+	}
+	expected := []string{"var a = 1;", "print a;", "var a = 1;"}
+	if !reflect.DeepEqual(h.entries, expected) {
+		t.Errorf("expected entries %q. got %q", expected, h.entries)
+	}
+	if got := loadHistory(file).entries; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected loaded entries %q. got %q", expected, got)
+	}
+
+	if got := loadHistory(filepath.Join(dir, "missing")).entries; len(got) != 0 {
+		t.Errorf("expected empty history of a missing file. got %q", got)
+	}
+	h = loadHistory("")
+	h.add("print 1;")
+	if !reflect.DeepEqual(h.entries, []string{"print 1;"}) {
+		t.Errorf("history without file should keep entries. got %q", h.entries)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lox-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "history")
+
+	var lines []string
+	for i := 0; i < maxHistory+10; i++ {
+		lines = append(lines, fmt.Sprintf("print %d;", i))
+	}
+	if err := ioutil.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h := loadHistory(file)
+	if len(h.entries) != maxHistory {
+		t.Fatalf("expected %d entries. got %d", maxHistory, len(h.entries))
+	}
+	if h.entries[0] != "print 10;" {
+		t.Errorf("expected the oldest entries to be dropped. got %q", h.entries[0])
+	}
+}
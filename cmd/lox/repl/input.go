@@ -0,0 +1,33 @@
+package repl
+
+// isIncomplete reports whether src needs more lines to be a complete input.
+// Input is incomplete when it has unclosed parentheses or braces, or an unterminated string.
+func isIncomplete(src string) bool {
+	depth := 0
+	inString, inComment := false, false
+	runes := []rune(src)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case inComment:
+			if ch == '\n' {
+				inComment = false
+			}
+		case inString:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			inComment = true
+		case ch == '(' || ch == '{':
+			depth++
+		case ch == ')' || ch == '}':
+			depth--
+		}
+	}
+	return inString || depth > 0
+}
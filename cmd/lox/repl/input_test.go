@@ -0,0 +1,29 @@
+package repl
+
+import "testing"
+
+func TestIsIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"", false},
+		{"print 1;", false},
+		{"fun f() {", true},
+		{"fun f() {\n  print 1;\n}", false},
+		{"print (1 +", true},
+		{"print (1 + 2);", false},
+		{`print "abc`, true},
+		{`print "a\"b`, true},
+		{`print "a\"b";`, false},
+		{`print "{";`, false},
+		{"print 1; // {", false},
+		{"// (\nprint (", true},
+		{"}", false},
+	}
+	for i, tt := range tests {
+		if got := isIncomplete(tt.input); got != tt.expected {
+			t.Errorf("test [%d]: isIncomplete(%q) expected %v. got %v", i, tt.input, tt.expected, got)
+		}
+	}
+}
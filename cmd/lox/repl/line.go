@@ -0,0 +1,288 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// errInterrupt is returned by ReadLine when user presses Ctrl-C.
+var errInterrupt = errors.New("interrupt")
+
+// lineReader reads input line by line.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+	AddHistory(line string)
+}
+
+func newLineReader(in io.Reader, out io.Writer, complete completer) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		return &terminal{
+			f:        f,
+			r:        bufio.NewReader(f),
+			out:      out,
+			history:  loadHistory(historyFile()),
+			complete: complete,
+		}
+	}
+	return &scanReader{
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+// scanReader reads lines from a non-interactive input.
+type scanReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (s *scanReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(s.out, prompt)
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+func (s *scanReader) AddHistory(line string) {}
+
+// terminal is a line editor for an interactive terminal.
+// It supports cursor movement, history navigation, completion and basic editing keys.
+type terminal struct {
+	f        *os.File
+	r        *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete completer
+
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+func (t *terminal) AddHistory(line string) {
+	t.history.add(line)
+}
+
+// ReadLine reads a line in raw mode.
+func (t *terminal) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.f.Fd())
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return t.readLine(prompt)
+}
+
+// readLine reads keys from t.r and edits the line until it is entered.
+func (t *terminal) readLine(prompt string) (string, error) {
+	t.prompt, t.buf, t.pos = prompt, nil, 0
+	histIdx, pending := len(t.history.entries), ""
+	t.refresh()
+	for {
+		ch, _, err := t.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch ch {
+		case '\r', '\n':
+			fmt.Fprint(t.out, "\r\n")
+			return string(t.buf), nil
+		case ctrl('C'):
+			fmt.Fprint(t.out, "^C\r\n")
+			return "", errInterrupt
+		case ctrl('D'):
+			if len(t.buf) == 0 {
+				fmt.Fprint(t.out, "\r\n")
+				return "", io.EOF
+			}
+			t.delete()
+		case 127, ctrl('H'):
+			t.backspace()
+		case ctrl('A'):
+			t.pos = 0
+		case ctrl('E'):
+			t.pos = len(t.buf)
+		case ctrl('B'):
+			t.left()
+		case ctrl('F'):
+			t.right()
+		case ctrl('K'):
+			t.buf = t.buf[:t.pos]
+		case ctrl('U'):
+			t.buf = t.buf[t.pos:]
+			t.pos = 0
+		case ctrl('W'):
+			t.deleteWord()
+		case ctrl('P'):
+			histIdx, pending = t.recall(histIdx, histIdx-1, pending)
+		case ctrl('N'):
+			histIdx, pending = t.recall(histIdx, histIdx+1, pending)
+		case '\t':
+			t.completeWord()
+		case 27:
+			switch t.readEscape() {
+			case 'A':
+				histIdx, pending = t.recall(histIdx, histIdx-1, pending)
+			case 'B':
+				histIdx, pending = t.recall(histIdx, histIdx+1, pending)
+			case 'C':
+				t.right()
+			case 'D':
+				t.left()
+			case 'H':
+				t.pos = 0
+			case 'F':
+				t.pos = len(t.buf)
+			case '3':
+				t.delete()
+			}
+		default:
+			if unicode.IsPrint(ch) {
+				t.insert(ch)
+			}
+		}
+		t.refresh()
+	}
+}
+
+// readEscape reads the rest of an escape sequence and returns its final character.
+// "ESC [ 3 ~" (delete) is reported as '3'.
+func (t *terminal) readEscape() rune {
+	ch, _, err := t.r.ReadRune()
+	if err != nil || (ch != '[' && ch != 'O') {
+		return 0
+	}
+	ch, _, err = t.r.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if ch < '0' || ch > '9' {
+		return ch
+	}
+	code := ch
+	for {
+		next, _, err := t.r.ReadRune()
+		if err != nil || next == '~' {
+			break
+		}
+	}
+	switch code {
+	case '1', '7':
+		return 'H'
+	case '4', '8':
+		return 'F'
+	}
+	return code
+}
+
+// completeWord completes the word before cursor.
+// Candidates are listed when the word can't be extended.
+// Tab indents when there is nothing to complete.
+func (t *terminal) completeWord() {
+	if t.pos == 0 || unicode.IsSpace(t.buf[t.pos-1]) {
+		t.insert(' ', ' ', ' ', ' ')
+		return
+	}
+	word, candidates := t.complete(t.buf, t.pos)
+	if len(candidates) == 0 {
+		return
+	}
+	prefix := commonPrefix(candidates)
+	if len(candidates) == 1 || len(prefix) > len(word) {
+		t.insert([]rune(prefix[len(word):])...)
+		return
+	}
+	fmt.Fprintf(t.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+// recall replaces the buffer with history entry to and returns the new index.
+// pending keeps the line being edited before browsing history.
+func (t *terminal) recall(from, to int, pending string) (int, string) {
+	entries := t.history.entries
+	if to < 0 || to > len(entries) {
+		return from, pending
+	}
+	if from == len(entries) {
+		pending = string(t.buf)
+	}
+	if to == len(entries) {
+		t.buf = []rune(pending)
+	} else {
+		t.buf = []rune(entries[to])
+	}
+	t.pos = len(t.buf)
+	return to, pending
+}
+
+func (t *terminal) insert(chars ...rune) {
+	buf := make([]rune, 0, len(t.buf)+len(chars))
+	buf = append(buf, t.buf[:t.pos]...)
+	buf = append(buf, chars...)
+	t.buf = append(buf, t.buf[t.pos:]...)
+	t.pos += len(chars)
+}
+
+func (t *terminal) backspace() {
+	if t.pos == 0 {
+		return
+	}
+	t.buf = append(t.buf[:t.pos-1], t.buf[t.pos:]...)
+	t.pos--
+}
+
+func (t *terminal) delete() {
+	if t.pos == len(t.buf) {
+		return
+	}
+	t.buf = append(t.buf[:t.pos], t.buf[t.pos+1:]...)
+}
+
+func (t *terminal) deleteWord() {
+	i := t.pos
+	for i > 0 && unicode.IsSpace(t.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(t.buf[i-1]) {
+		i--
+	}
+	t.buf = append(t.buf[:i], t.buf[t.pos:]...)
+	t.pos = i
+}
+
+func (t *terminal) left() {
+	if t.pos > 0 {
+		t.pos--
+	}
+}
+
+func (t *terminal) right() {
+	if t.pos < len(t.buf) {
+		t.pos++
+	}
+}
+
+// refresh redraws prompt and buffer, and moves cursor to its position.
+func (t *terminal) refresh() {
+	var sb strings.Builder
+	sb.WriteString("\r")
+	sb.WriteString(t.prompt)
+	sb.WriteString(string(t.buf))
+	sb.WriteString("\x1b[K\r")
+	if col := len([]rune(t.prompt)) + t.pos; col > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dC", col)
+	}
+	fmt.Fprint(t.out, sb.String())
+}
+
+func ctrl(ch rune) rune {
+	return ch & 0x1f
+}
@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func newTestTerminal(input string, entries ...string) *terminal {
+	return &terminal{
+		r:       bufio.NewReader(strings.NewReader(input)),
+		out:     ioutil.Discard,
+		history: &history{entries: entries},
+		complete: func(line []rune, pos int) (string, []string) {
+			start := pos
+			for start > 0 && isIdentChar(line[start-1]) {
+				start--
+			}
+			word := string(line[start:pos])
+			var candidates []string
+			for _, name := range []string{"print", "printer", "var"} {
+				if strings.HasPrefix(name, word) {
+					candidates = append(candidates, name)
+				}
+			}
+			return word, candidates
+		},
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	const (
+		left      = "\x1b[D"
+		right     = "\x1b[C"
+		up        = "\x1b[A"
+		down      = "\x1b[B"
+		home      = "\x1b[H"
+		end       = "\x1b[F"
+		del       = "\x1b[3~"
+		backspace = "\x7f"
+	)
+	tests := []struct {
+		input    string
+		history  []string
+		expected string
+		err      error
+	}{
+		{"print 1;\r", nil, "print 1;", nil},
+		{"print 1;\n", nil, "print 1;", nil},
+		{"print 12" + backspace + ";\r", nil, "print 1;", nil},
+		{"print 1" + left + "2\r", nil, "print 21", nil},
+		{"rint 1;" + home + "p" + end + " \r", nil, "print 1; ", nil},
+		{"ab" + "\x01" + "x" + "\x05" + "y\r", nil, "xaby", nil},
+		{"abc" + "\x02\x02" + "\x06" + "X\r", nil, "abXc", nil},
+		{"abc" + left + left + del + "\r", nil, "ac", nil},
+		{"abc" + left + "\x04\r", nil, "ab", nil},
+		{"abcd" + left + left + "\x0b\r", nil, "ab", nil},
+		{"abcd" + left + "\x15\r", nil, "d", nil},
+		{"var ab = 1" + "\x17\x17\r", nil, "var ab ", nil},
+		{right + "a" + left + left + "b\r", nil, "ba", nil},
+		{up + "\r", []string{"print 1;", "print 2;"}, "print 2;", nil},
+		{up + up + "\r", []string{"print 1;", "print 2;"}, "print 1;", nil},
+		{up + up + up + "\r", []string{"print 1;", "print 2;"}, "print 1;", nil},
+		{"x" + up + down + "\r", []string{"print 1;"}, "x", nil},
+		{"\x10" + "\x10" + "\x0e" + "\r", []string{"a", "b"}, "b", nil},
+		{"pr\t\r", nil, "print", nil},
+		{"va\t 1;\r", nil, "var 1;", nil},
+		{"\tx\r", nil, "    x", nil},
+		{"q\t\r", nil, "q", nil},
+		{"abc\x03", nil, "", errInterrupt},
+		{"\x04", nil, "", io.EOF},
+		{"abc", nil, "", io.EOF},
+	}
+	for i, tt := range tests {
+		line, err := newTestTerminal(tt.input, tt.history...).readLine(">> ")
+		if line != tt.expected || err != tt.err {
+			t.Errorf("test [%d]: expected %q (%v). got %q (%v)", i, tt.expected, tt.err, line, err)
+		}
+	}
+}
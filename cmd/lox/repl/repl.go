@@ -1,42 +1,60 @@
 package repl
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strings"
 
 	"github.com/ziyoung/lox-go/interpreter"
-	"github.com/ziyoung/lox-go/lexer"
-	"github.com/ziyoung/lox-go/parser"
 )
 
-const prompt = ">> "
+const (
+	prompt             = ">> "
+	continuationPrompt = ".. "
+)
 
 // Start creates a REPL for Lox.
+// Input spanning several lines is read until braces, parentheses and strings are closed.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	r := newLineReader(in, out, complete)
+	s := &session{out: out}
 	interpreter.SetEvalEnv("repl")
+	var lines []string
 	for {
-		fmt.Fprintf(out, prompt)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
+		ps := prompt
+		if len(lines) != 0 {
+			ps = continuationPrompt
 		}
-
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) == 0 {
+		line, err := r.ReadLine(ps)
+		if err == errInterrupt {
+			lines = nil
 			continue
 		}
-		if line == "exit" {
-			fmt.Fprintln(out, "bye.")
+		if err != nil {
 			return
 		}
-		l := lexer.New(line)
-		p := parser.New(l)
-		statements, err := p.Parse()
-		if err == nil && len(statements) != 0 {
-			interpreter.Interpret(statements)
+		r.AddHistory(line)
+
+		if len(lines) == 0 {
+			line = strings.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			if line == "exit" {
+				fmt.Fprintln(out, "bye.")
+				return
+			}
+			if strings.HasPrefix(line, ":") {
+				s.exec(line)
+				continue
+			}
+		}
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		if isIncomplete(input) {
+			continue
 		}
+		lines = nil
+		s.run(input)
 	}
 }
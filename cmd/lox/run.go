@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/profile"
+)
+
+func runMain(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	profileFile := flags.String("profile", "", "write a pprof profile of Lox functions and lines to `file`")
+	noOpt := flags.Bool("noopt", false, "disable constant folding and dead code elimination")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox run [-noopt] [-profile file] file.lox")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	name := flags.Arg(0)
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	statements, err := parser.ParseStmts(string(src))
+	if err != nil {
+		return 1
+	}
+	if err := setFile(name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	interpreter.SetOptimize(!*noOpt)
+	if *profileFile == "" {
+		interpreter.Interpret(statements)
+		return 0
+	}
+
+	p := profile.New(name)
+	p.Start()
+	interpreter.Interpret(statements)
+	p.Stop()
+	if err := writeFile(*profileFile, p.Write); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
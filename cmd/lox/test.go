@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ziyoung/lox-go/cover"
+	"github.com/ziyoung/lox-go/loxtest"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func testMain(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	verbose := flags.Bool("v", false, "print results of all tests, not only failed ones")
+	junit := flags.String("junit", "", "write a JUnit XML report to `file`")
+	coverMode := flags.Bool("cover", false, "print statement and branch coverage")
+	coverProfile := flags.String("coverprofile", "", "write an LCOV coverage report to `file`; implies -cover")
+	coverHTML := flags.String("coverhtml", "", "write an HTML coverage report to `file`; implies -cover")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox test [-v] [-junit file] [-cover] [-coverprofile file] [-coverhtml file] [path ...]")
+		fmt.Fprintln(os.Stderr, "Test files are files named *_test.lox in the given directories, by default the current one.")
+		fmt.Fprintln(os.Stderr, "Tests are functions named test* without parameters; a file without tests is run as a script.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := testFiles(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "no test files")
+		return 1
+	}
+
+	var c *cover.Coverage
+	if *coverMode || *coverProfile != "" || *coverHTML != "" {
+		c = cover.New()
+	}
+	code := 0
+	var suites []*loxtest.Suite
+	for _, file := range files {
+		suite := runTestFile(file, c)
+		printSuite(suite, *verbose)
+		if suite.Failed() {
+			code = 1
+		}
+		suites = append(suites, suite)
+	}
+
+	if *junit != "" {
+		err := writeFile(*junit, func(w io.Writer) error {
+			return loxtest.WriteJUnit(w, suites)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if c == nil {
+		return code
+	}
+	fmt.Println(c.Summary())
+	if *coverProfile != "" {
+		if err := writeFile(*coverProfile, c.WriteLCOV); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if *coverHTML != "" {
+		if err := writeFile(*coverHTML, c.WriteHTML); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return code
+}
+
+// testFiles returns files in paths. Directories are searched for *_test.lox files.
+func testFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		dirFiles, err := loxFiles([]string{path})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range dirFiles {
+			if loxtest.IsTestFile(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// runTestFile runs tests of file, recording coverage if c is not nil.
+func runTestFile(file string, c *cover.Coverage) *loxtest.Suite {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return &loxtest.Suite{File: file, Err: err}
+	}
+	statements, err := parser.ParseStmts(string(src))
+	if err != nil {
+		return &loxtest.Suite{File: file, Err: err}
+	}
+	if err := setFile(file); err != nil {
+		return &loxtest.Suite{File: file, Err: err}
+	}
+	if c != nil {
+		c.Add(file, src, statements)
+		c.Start()
+		defer c.Stop()
+	}
+	return loxtest.Run(file, statements)
+}
+
+func printSuite(s *loxtest.Suite, verbose bool) {
+	for _, r := range s.Tests {
+		if r.Err != nil {
+			fmt.Printf("--- FAIL: %s (%.3fs)\n    %s\n", r.Name, r.Time.Seconds(), s.Message(r.Err))
+		} else if verbose {
+			fmt.Printf("--- PASS: %s (%.3fs)\n", r.Name, r.Time.Seconds())
+		}
+	}
+	if s.Err != nil {
+		fmt.Printf("    %s\n", s.Message(s.Err))
+	}
+	status := "ok"
+	if s.Failed() {
+		status = "FAIL"
+	}
+	fmt.Printf("%s\t%s\t%.3fs\n", status, s.File, s.Time.Seconds())
+}
+
+// writeFile creates file and writes to it by write.
+func writeFile(file string, write func(w io.Writer) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
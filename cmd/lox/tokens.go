@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/token"
+)
+
+type jsonTrivia struct {
+	Text string         `json:"text"`
+	Pos  token.Position `json:"pos"`
+}
+
+type jsonToken struct {
+	Kind    token.Token    `json:"kind"`
+	Literal string         `json:"literal"`
+	Text    string         `json:"text"`
+	Pos     token.Position `json:"pos"`
+	End     token.Position `json:"end"`
+	Leading []jsonTrivia   `json:"leading,omitempty"`
+}
+
+func tokensMain(args []string) int {
+	flags := flag.NewFlagSet("tokens", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print tokens as JSON")
+	trivia := flags.Bool("trivia", false, "keep whitespace and comments as trivia of tokens")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox tokens [-json] [-trivia] file.lox")
+		fmt.Fprintln(os.Stderr, "Prints the kind, literal and position of each token of the file.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	src, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var items []lexer.Item
+	lexer.All(string(src), *trivia)(func(item lexer.Item) bool {
+		items = append(items, item)
+		return true
+	})
+
+	if *asJSON {
+		tokens := make([]jsonToken, 0, len(items))
+		for _, item := range items {
+			tok := jsonToken{
+				Kind:    item.Token,
+				Literal: item.Literal,
+				Text:    item.Text,
+				Pos:     item.Pos,
+				End:     item.End,
+			}
+			for _, t := range item.Leading {
+				tok.Leading = append(tok.Leading, jsonTrivia{t.Text, t.Pos})
+			}
+			tokens = append(tokens, tok)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tokens); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, item := range items {
+		for _, t := range item.Leading {
+			kind := "whitespace"
+			if t.IsComment() {
+				kind = "comment"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%q\n", t.Pos, kind, t.Text)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%q\n", item.Pos, item.Token, item.Literal)
+	}
+	w.Flush()
+	return 0
+}
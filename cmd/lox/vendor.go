@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/mod"
+)
+
+func vendorMain(args []string) int {
+	flags := flag.NewFlagSet("vendor", flag.ExitOnError)
+	verify := flags.Bool("verify", false, "check lox_modules against lox.sum instead of vendoring")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: lox vendor [-verify] [dir]")
+		fmt.Fprintln(os.Stderr, "Copies packages required by lox.mod in dir or its parents into lox_modules.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	m, err := mod.Find(dir)
+	if err == nil && m == nil {
+		err = fmt.Errorf("no %s found in %s or its parents", mod.ManifestFile, dir)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *verify {
+		if err := mod.Verify(m); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println("all packages verified")
+		return 0
+	}
+	packages, err := mod.Vendor(m)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, p := range packages {
+		fmt.Printf("%s\t%s\t%d files\n", p.Name, p.Dir, len(p.Files))
+	}
+	return 0
+}
+
+// setFile sets the file of the program to be run, and resolves imports of packages
+// vendored for the manifest of the file if there is one.
+func setFile(file string) error {
+	interpreter.SetFile(file)
+	interpreter.SetResolver(nil)
+	m, err := mod.Find(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		interpreter.SetResolver(m.Resolve)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/resolver"
+	"github.com/ziyoung/lox-go/token"
+)
+
+const (
+	expectOutput       = "// expect: "
+	expectRuntimeError = "// expect runtime error: "
+	expectError        = "// expect error: "
+)
+
+// expectation is the expected result of a program.
+type expectation struct {
+	output []string
+	// kind is "runtime error" or "error" if an error is expected, or empty.
+	kind string
+	msg  string
+	line int
+}
+
+func parseExpectation(src string) (*expectation, error) {
+	e := &expectation{}
+	for i, line := range strings.Split(src, "\n") {
+		if j := strings.Index(line, expectOutput); j >= 0 {
+			e.output = append(e.output, line[j+len(expectOutput):])
+			continue
+		}
+		for _, c := range []struct{ prefix, kind string }{
+			{expectRuntimeError, "runtime error"},
+			{expectError, "error"},
+		} {
+			if j := strings.Index(line, c.prefix); j >= 0 {
+				if e.kind != "" {
+					return nil, fmt.Errorf("line %d: more than one error expected", i+1)
+				}
+				e.kind, e.msg, e.line = c.kind, line[j+len(c.prefix):], i+1
+			}
+		}
+	}
+	if e.kind == "error" && len(e.output) != 0 {
+		return nil, fmt.Errorf("output expected with a compile error")
+	}
+	return e, nil
+}
+
+// positionError is an error with a position, which parse and runtime errors are.
+type positionError interface {
+	error
+	Pos() token.Position
+	Message() string
+}
+
+// run runs src and returns its output, and the kind, message and line of the error if any.
+func run(src string) (output []string, kind, msg string, line int) {
+	var buf bytes.Buffer
+	interpreter.Reset()
+	interpreter.SetOutput(&buf)
+	defer interpreter.SetOutput(nil)
+
+	kind = "error"
+	statements, err := parser.ParseStmts(src)
+	if err == nil {
+		_, err = resolver.Analyze(statements)
+	}
+	if err == nil {
+		kind = "runtime error"
+		err = interpreter.Execute(statements)
+	}
+	if s := strings.TrimSuffix(buf.String(), "\n"); s != "" {
+		output = strings.Split(s, "\n")
+	}
+	if err == nil {
+		return output, "", "", 0
+	}
+	if perr, ok := err.(positionError); ok {
+		return output, kind, perr.Message(), perr.Pos().Line
+	}
+	return output, kind, err.Error(), 0
+}
+
+func TestConformance(t *testing.T) {
+	var files []string
+	err := filepath.Walk("testdata", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".lox") {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test programs")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.ToSlash(strings.TrimPrefix(file, "testdata"+string(filepath.Separator))), ".lox")
+		t.Run(name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := parseExpectation(string(b))
+			if err != nil {
+				t.Fatalf("invalid expectation: %s", err)
+			}
+			output, kind, msg, line := run(string(b))
+
+			for i := 0; i < len(output) || i < len(e.output); i++ {
+				switch {
+				case i >= len(output):
+					t.Errorf("missing output %q", e.output[i])
+				case i >= len(e.output):
+					t.Errorf("unexpected output %q", output[i])
+				case output[i] != e.output[i]:
+					t.Errorf("output %d wrong. expected=%q, got=%q", i+1, e.output[i], output[i])
+				}
+			}
+			switch {
+			case kind == "" && e.kind != "":
+				t.Errorf("expected %s at line %d: %s", e.kind, e.line, e.msg)
+			case kind != "" && e.kind == "":
+				t.Errorf("unexpected %s at line %d: %s", kind, line, msg)
+			case kind != e.kind || msg != e.msg || line != e.line:
+				t.Errorf("expected %s at line %d: %s, got %s at line %d: %s", e.kind, e.line, e.msg, kind, line, msg)
+			}
+		})
+	}
+}
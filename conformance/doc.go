@@ -0,0 +1,16 @@
+// Package conformance holds a corpus of Lox programs in testdata, which is run
+// by the tests of the package to check the lexer, parser, resolver and
+// interpreter together.
+//
+// Expected results are written as comments in the programs, in the style of the
+// test suite of Crafting Interpreters:
+//
+//	print 1 + 2;       // expect: 3
+//	print -"a";        // expect runtime error: Operand must be a number.
+//	return 1;          // expect error: Cannot return from top-level code.
+//
+// Each "expect:" comment is a line the program prints, in order. A program may
+// expect one error, either a runtime error or a compile error, which is a parse
+// or resolve error. The error must occur on the line of the comment. A program
+// with a compile error doesn't run, so it expects no output.
+package conformance
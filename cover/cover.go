@@ -0,0 +1,181 @@
+// Package cover records statement and branch coverage of Lox programs using the
+// hooks of the interpreter, and writes LCOV and HTML reports.
+//
+// Branches are those of if statements, whether the then or the else branch runs,
+// and of logical expressions, whether the right operand is evaluated.
+package cover
+
+import (
+	"fmt"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/interpreter"
+)
+
+// Statement is a statement and the number of times it ran.
+type Statement struct {
+	Stmt  ast.Stmt
+	Count int
+}
+
+// Branch is an if statement or a logical expression, and the number of times each
+// of its two branches is taken. The branches are numbered as by interpreter.SetBranchHook.
+type Branch struct {
+	Node   ast.Node
+	Counts [2]int
+}
+
+// File is the coverage of a source file.
+type File struct {
+	Name       string
+	Src        []byte
+	Statements []*Statement
+	Branches   []*Branch
+}
+
+// Coverage records coverage of files while it is started.
+type Coverage struct {
+	Files []*File
+
+	statements map[ast.Stmt]*Statement
+	branches   map[ast.Node]*Branch
+}
+
+// New returns an empty coverage.
+func New() *Coverage {
+	return &Coverage{
+		statements: make(map[ast.Stmt]*Statement),
+		branches:   make(map[ast.Node]*Branch),
+	}
+}
+
+// Add adds the file name with source src, parsed as statements. Only statements
+// of added files are recorded.
+func (c *Coverage) Add(name string, src []byte, statements []ast.Stmt) *File {
+	f := &File{Name: name, Src: src}
+	for _, stmt := range statements {
+		c.collect(f, stmt)
+	}
+	c.Files = append(c.Files, f)
+	return f
+}
+
+// Start starts recording by setting the hooks of the interpreter.
+func (c *Coverage) Start() {
+	interpreter.SetHook(c.statement)
+	interpreter.SetBranchHook(c.branch)
+}
+
+// Stop stops recording and removes the hooks.
+func (c *Coverage) Stop() {
+	interpreter.SetHook(nil)
+	interpreter.SetBranchHook(nil)
+}
+
+func (c *Coverage) statement(stmt ast.Stmt) {
+	if s, ok := c.statements[stmt]; ok {
+		s.Count++
+	}
+}
+
+func (c *Coverage) branch(node ast.Node, branch int) {
+	if b, ok := c.branches[node]; ok {
+		b.Counts[branch]++
+	}
+}
+
+// collect collects statements and branches in stmt. Statements are collected only
+// if they are executed as statements, which excludes methods and initializers of
+// for loops.
+func (c *Coverage) collect(f *File, stmt ast.Stmt) {
+	skipped := make(map[ast.Stmt]bool)
+	ast.Inspect(stmt, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.ClassStmt:
+			for _, method := range n.Methods {
+				skipped[method] = true
+			}
+		case *ast.ForStmt:
+			// the initializer is evaluated as a part of the loop.
+			skipped[n.Initializer] = true
+		case *ast.IfStmt, *ast.LogicalExpr:
+			c.addBranch(f, n)
+		}
+		if s, ok := node.(ast.Stmt); ok && !skipped[s] {
+			st := &Statement{Stmt: s}
+			c.statements[s] = st
+			f.Statements = append(f.Statements, st)
+		}
+		return true
+	})
+}
+
+func (c *Coverage) addBranch(f *File, node ast.Node) {
+	b := &Branch{Node: node}
+	c.branches[node] = b
+	f.Branches = append(f.Branches, b)
+}
+
+// Summary counts statements and branches, and those covered.
+type Summary struct {
+	Statements, CoveredStatements int
+	Branches, CoveredBranches     int
+}
+
+func (s *Summary) add(f *File) {
+	for _, stmt := range f.Statements {
+		s.Statements++
+		if stmt.Count > 0 {
+			s.CoveredStatements++
+		}
+	}
+	for _, b := range f.Branches {
+		for _, n := range b.Counts {
+			s.Branches++
+			if n > 0 {
+				s.CoveredBranches++
+			}
+		}
+	}
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("coverage: %.1f%% of statements, %.1f%% of branches",
+		percent(s.CoveredStatements, s.Statements), percent(s.CoveredBranches, s.Branches))
+}
+
+// Summary returns the summary of the file.
+func (f *File) Summary() Summary {
+	var s Summary
+	s.add(f)
+	return s
+}
+
+// Summary returns the summary of all files.
+func (c *Coverage) Summary() Summary {
+	var s Summary
+	for _, f := range c.Files {
+		s.add(f)
+	}
+	return s
+}
+
+// lines returns the number of times each line ran, for lines where statements start.
+// A line runs as many times as the most run statement on it.
+func (f *File) lines() map[int]int {
+	lines := make(map[int]int)
+	for _, s := range f.Statements {
+		line := s.Stmt.Pos().Line
+		if n, ok := lines[line]; !ok || s.Count > n {
+			lines[line] = s.Count
+		}
+	}
+	return lines
+}
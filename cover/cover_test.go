@@ -0,0 +1,139 @@
+package cover
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+const program = `fun clamp(x, lo, hi) {
+  if (x < lo) return lo;
+  if (x > hi) return hi;
+  return x;
+}
+for (var i = 0; i < 2; i = i + 1) {
+  print clamp(i * 2, 0, 1) == 0 and true;
+}
+`
+
+func run(t *testing.T) *Coverage {
+	statements, err := parser.ParseStmts(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New()
+	c.Add("clamp.lox", []byte(program), statements)
+	interpreter.Reset()
+	interpreter.SetOutput(&bytes.Buffer{})
+	defer interpreter.SetOutput(nil)
+	c.Start()
+	defer c.Stop()
+	if err := interpreter.Execute(statements); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCoverage(t *testing.T) {
+	c := run(t)
+	f := c.Files[0]
+
+	counts := make(map[int][]int)
+	for _, s := range f.Statements {
+		line := s.Stmt.Pos().Line
+		counts[line] = append(counts[line], s.Count)
+	}
+	statements := []struct {
+		line   int
+		counts []int
+	}{
+		{1, []int{1}},
+		{2, []int{2, 0}}, // if and return lo
+		{3, []int{2, 1}},
+		{4, []int{1}},
+		{6, []int{1, 2}}, // for and its body
+		{7, []int{2}},
+	}
+	for _, tt := range statements {
+		if got := counts[tt.line]; len(got) != len(tt.counts) || !equal(got, tt.counts) {
+			t.Errorf("counts at line %d wrong. expected=%v, got=%v", tt.line, tt.counts, got)
+		}
+	}
+
+	branches := [][2]int{{0, 2}, {1, 1}, {1, 1}}
+	if len(f.Branches) != len(branches) {
+		t.Fatalf("wrong number of branches. expected=%d, got=%d", len(branches), len(f.Branches))
+	}
+	for i, b := range f.Branches {
+		if b.Counts != branches[i] {
+			t.Errorf("branches[%d] wrong. expected=%v, got=%v", i, branches[i], b.Counts)
+		}
+	}
+
+	s := c.Summary()
+	expected := Summary{Statements: 9, CoveredStatements: 8, Branches: 6, CoveredBranches: 5}
+	if s != expected {
+		t.Errorf("summary wrong. expected=%+v, got=%+v", expected, s)
+	}
+}
+
+func equal(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWriteLCOV(t *testing.T) {
+	c := run(t)
+	var buf bytes.Buffer
+	if err := c.WriteLCOV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `TN:
+SF:clamp.lox
+DA:1,1
+DA:2,2
+DA:3,2
+DA:4,1
+DA:6,2
+DA:7,2
+BRDA:2,0,0,0
+BRDA:2,0,1,2
+BRDA:3,1,0,1
+BRDA:3,1,1,1
+BRDA:7,2,0,1
+BRDA:7,2,1,1
+BRF:6
+BRH:5
+LF:6
+LH:6
+end_of_record
+`
+	if buf.String() != expected {
+		t.Errorf("lcov wrong. expected=\n%s\ngot=\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	c := run(t)
+	var buf bytes.Buffer
+	if err := c.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, s := range []string{
+		`<tr class="partial"><td class="num">2</td><td class="count">2</td><td class="src">  if (x &lt; lo) return lo;</td><td class="branches">then 0 / else 2</td></tr>`,
+		`<tr class="covered"><td class="num">4</td>`,
+		`<tr class=""><td class="num">5</td><td class="count"></td><td class="src">}</td>`,
+	} {
+		if !strings.Contains(html, s) {
+			t.Errorf("html doesn't contain %q", s)
+		}
+	}
+}
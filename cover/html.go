@@ -0,0 +1,110 @@
+package cover
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ziyoung/lox-go/ast"
+)
+
+var htmlTemplate = template.Must(template.New("cover").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Lox coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; }
+td { padding: 0 8px; white-space: pre; vertical-align: top; }
+td.num, td.count { color: #888; text-align: right; }
+td.branches { color: #888; }
+tr.covered td.src { background: #dfd; }
+tr.uncovered td.src { background: #fdd; }
+tr.partial td.src { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>{{.Summary}}</h1>
+<ul>
+{{- range $i, $f := .Files}}
+<li><a href="#file{{$i}}">{{$f.Name}}</a>: {{$f.Summary}}</li>
+{{- end}}
+</ul>
+{{- range $i, $f := .Files}}
+<h2 id="file{{$i}}">{{$f.Name}}</h2>
+<table>
+{{- range $f.Lines}}
+<tr class="{{.Class}}"><td class="num">{{.Number}}</td><td class="count">{{.Count}}</td><td class="src">{{.Text}}</td><td class="branches">{{.Branches}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlLine struct {
+	Number int
+	Text   string
+	// Count is the number of times the line ran, empty if no statement starts on it.
+	Count    string
+	Class    string
+	Branches string
+}
+
+type htmlFile struct {
+	Name    string
+	Summary Summary
+	Lines   []htmlLine
+}
+
+// WriteHTML writes the coverage as an HTML page showing sources annotated with the
+// number of times each line ran and branches taken. Lines are marked as covered,
+// uncovered or partial, which means some branch on the line is never taken.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	data := struct {
+		Summary Summary
+		Files   []htmlFile
+	}{Summary: c.Summary()}
+	for _, f := range c.Files {
+		data.Files = append(data.Files, f.html())
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+func (f *File) html() htmlFile {
+	counts := f.lines()
+	branches := make(map[int][]string)
+	partial := make(map[int]bool)
+	for _, b := range f.Branches {
+		line := b.Node.Pos().Line
+		names := [2]string{"right", "short-circuit"}
+		if _, ok := b.Node.(*ast.IfStmt); ok {
+			names = [2]string{"then", "else"}
+		}
+		branches[line] = append(branches[line], fmt.Sprintf("%s %d / %s %d", names[0], b.Counts[0], names[1], b.Counts[1]))
+		if b.Counts[0] == 0 || b.Counts[1] == 0 {
+			partial[line] = true
+		}
+	}
+
+	hf := htmlFile{Name: f.Name, Summary: f.Summary()}
+	for i, text := range strings.Split(strings.TrimSuffix(string(f.Src), "\n"), "\n") {
+		line := htmlLine{Number: i + 1, Text: text, Branches: strings.Join(branches[i+1], ", ")}
+		if n, ok := counts[i+1]; ok {
+			line.Count = strconv.Itoa(n)
+			switch {
+			case n == 0:
+				line.Class = "uncovered"
+			case partial[i+1]:
+				line.Class = "partial"
+			default:
+				line.Class = "covered"
+			}
+		}
+		hf.Lines = append(hf.Lines, line)
+	}
+	return hf
+}
@@ -0,0 +1,47 @@
+package cover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WriteLCOV writes the coverage in the LCOV tracefile format, with line (DA) and
+// branch (BRDA) records. Each branch node is a block of two branches.
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range c.Files {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", f.Name)
+
+		lines := f.lines()
+		numbers := make([]int, 0, len(lines))
+		for line := range lines {
+			numbers = append(numbers, line)
+		}
+		sort.Ints(numbers)
+		hit := 0
+		for _, line := range numbers {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, lines[line])
+			if lines[line] > 0 {
+				hit++
+			}
+		}
+
+		s := f.Summary()
+		for i, b := range f.Branches {
+			line := b.Node.Pos().Line
+			for j, n := range b.Counts {
+				if b.Counts[0]+b.Counts[1] == 0 {
+					// "-" means the branch node itself never ran.
+					fmt.Fprintf(bw, "BRDA:%d,%d,%d,-\n", line, i, j)
+				} else {
+					fmt.Fprintf(bw, "BRDA:%d,%d,%d,%d\n", line, i, j, n)
+				}
+			}
+		}
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", s.Branches, s.CoveredBranches)
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+	return bw.Flush()
+}
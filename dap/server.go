@@ -0,0 +1,357 @@
+// Package dap implements a Debug Adapter Protocol server for Lox.
+//
+// The server debugs a single program given by the launch request. The program
+// runs on its own goroutine, which is the only thread reported to the client.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/debug"
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/mod"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// threadID is the ID of the only thread.
+const threadID = 1
+
+// Server is a debug adapter reading requests from in and writing responses and events to out.
+type Server struct {
+	in *bufio.Reader
+
+	// mu guards out and seq, which are used by the program goroutine too.
+	mu  sync.Mutex
+	out io.Writer
+	seq int
+
+	dbg         *debug.Debugger
+	program     string
+	statements  []ast.Stmt
+	stopOnEntry bool
+	// done is closed when the program finishes. It is nil before the program starts.
+	done chan struct{}
+
+	// refs maps variable references to environments and objects of the paused program.
+	refs map[int]interface{}
+}
+
+// NewServer returns a server reading from in and writing to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:  bufio.NewReader(in),
+		out: out,
+		dbg: debug.New(),
+	}
+	s.dbg.OnStop = func(stop debug.Stop) {
+		s.event("stopped", StoppedEvent{Reason: stop.Reason, ThreadID: threadID, AllThreadsStopped: true})
+	}
+	return s
+}
+
+// Serve handles requests until the disconnect request or the end of input.
+// The program is terminated before Serve returns.
+func (s *Server) Serve() error {
+	defer s.terminate()
+	for {
+		b, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			return err
+		}
+		body, err := s.handle(&req)
+		resp := &response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		if err := s.send(resp, &resp.Seq); err != nil {
+			return err
+		}
+		switch req.Command {
+		case "initialize":
+			s.event("initialized", nil)
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+// send writes a message after setting its sequence number.
+func (s *Server) send(msg interface{}, seq *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	*seq = s.seq
+	return writeMessage(s.out, msg)
+}
+
+func (s *Server) event(name string, body interface{}) {
+	e := &event{Type: "event", Event: name, Body: body}
+	s.send(e, &e.Seq)
+}
+
+// terminate stops the program if it is running and waits for it.
+func (s *Server) terminate() {
+	if s.done == nil {
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		s.dbg.Terminate()
+		<-s.done
+	}
+}
+
+func (s *Server) handle(req *request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsConditionalBreakpoints:   true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		}, nil
+	case "launch":
+		var args LaunchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "setBreakpoints":
+		var args SetBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args), nil
+	case "configurationDone":
+		return nil, s.start()
+	case "threads":
+		return map[string]interface{}{"threads": []Thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args ScopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args VariablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args EvaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		v, err := s.dbg.Evaluate(args.Expression, args.FrameID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"result":             display(v),
+			"type":               v.Type().String(),
+			"variablesReference": s.reference(v),
+		}, nil
+	case "continue":
+		return map[string]interface{}{"allThreadsContinued": true}, s.resume(s.dbg.Continue)
+	case "next":
+		return nil, s.resume(s.dbg.StepOver)
+	case "stepIn":
+		return nil, s.resume(s.dbg.StepIn)
+	case "stepOut":
+		return nil, s.resume(s.dbg.StepOut)
+	case "pause":
+		s.dbg.Pause()
+		return nil, nil
+	case "terminate", "disconnect":
+		s.terminate()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported command %s", req.Command)
+}
+
+func (s *Server) launch(args LaunchArguments) error {
+	if s.done != nil {
+		return errors.New("program is already launched")
+	}
+	src, err := ioutil.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	statements, err := parser.ParseStmts(string(src))
+	if err != nil {
+		return err
+	}
+	s.program, s.statements, s.stopOnEntry = args.Program, statements, args.StopOnEntry
+	return nil
+}
+
+// start runs the launched program on a new goroutine.
+func (s *Server) start() error {
+	if s.statements == nil {
+		return errors.New("no program is launched")
+	}
+	if s.done != nil {
+		return nil
+	}
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		interpreter.Reset()
+		interpreter.SetFile(s.program)
+		interpreter.SetResolver(nil)
+		if m, err := mod.Find(filepath.Dir(s.program)); err != nil {
+			s.event("output", OutputEvent{Category: "stderr", Output: err.Error() + "\n"})
+		} else if m != nil {
+			interpreter.SetResolver(m.Resolve)
+		}
+		interpreter.SetOutput(&output{s, "stdout"})
+		defer interpreter.SetOutput(nil)
+
+		code := 0
+		if err := s.dbg.Run(s.statements, s.stopOnEntry); err != nil {
+			s.event("output", OutputEvent{Category: "stderr", Output: err.Error() + "\n"})
+			code = 1
+		}
+		s.event("exited", ExitedEvent{ExitCode: code})
+		s.event("terminated", nil)
+	}()
+	return nil
+}
+
+func (s *Server) setBreakpoints(args SetBreakpointsArguments) interface{} {
+	s.dbg.ClearBreakpoints(args.Source.Path)
+	breakpoints := []Breakpoint{}
+	for _, sbp := range args.Breakpoints {
+		bp := Breakpoint{Verified: true, Line: sbp.Line}
+		if _, err := s.dbg.SetBreakpoint(args.Source.Path, sbp.Line, sbp.Condition); err != nil {
+			bp.Verified, bp.Message = false, err.Error()
+		}
+		breakpoints = append(breakpoints, bp)
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}
+}
+
+// resume resumes the program and discards variable references of the current stop.
+func (s *Server) resume(fn func() error) error {
+	s.refs = nil
+	return fn()
+}
+
+func (s *Server) stackTrace() (interface{}, error) {
+	frames, err := s.dbg.Frames()
+	if err != nil {
+		return nil, err
+	}
+	stackFrames := make([]StackFrame, len(frames))
+	for i, f := range frames {
+		source := Source{Name: filepath.Base(f.File), Path: f.File}
+		stackFrames[i] = StackFrame{ID: i, Name: f.Function, Source: source, Line: f.Pos.Line, Column: f.Pos.Column}
+	}
+	return map[string]interface{}{"stackFrames": stackFrames, "totalFrames": len(frames)}, nil
+}
+
+func (s *Server) scopes(frame int) (interface{}, error) {
+	scopes, err := s.dbg.Scopes(frame)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Scope, len(scopes))
+	for i, scope := range scopes {
+		result[i] = Scope{Name: scope.Name, VariablesReference: s.reference(scope.Env), Expensive: scope.Name == "Globals"}
+	}
+	return map[string]interface{}{"scopes": result}, nil
+}
+
+func (s *Server) variables(ref int) (interface{}, error) {
+	variables := []Variable{}
+	switch v := s.refs[ref].(type) {
+	case *valuer.Environment:
+		for _, name := range debug.Names(v) {
+			variables = append(variables, s.variable(name, v.Values[name]))
+		}
+	case *valuer.Instance:
+		names := append([]string(nil), v.FieldNames()...)
+		sort.Strings(names)
+		for _, name := range names {
+			field, _ := v.Field(name)
+			variables = append(variables, s.variable(name, field))
+		}
+	case *valuer.GoObject:
+		for _, name := range v.Fields() {
+			if field, err := v.Get(name); err == nil {
+				variables = append(variables, s.variable(name, field))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid variables reference %d", ref)
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+func (s *Server) variable(name string, v valuer.Valuer) Variable {
+	return Variable{Name: name, Value: display(v), Type: v.Type().String(), VariablesReference: s.reference(v)}
+}
+
+// reference returns a new variables reference for environments and objects with fields, or 0.
+func (s *Server) reference(v interface{}) int {
+	switch v := v.(type) {
+	case *valuer.Instance:
+		if len(v.Fields) == 0 {
+			return 0
+		}
+	case *valuer.GoObject:
+		if len(v.Fields()) == 0 {
+			return 0
+		}
+	case *valuer.Environment:
+	default:
+		return 0
+	}
+	if s.refs == nil {
+		s.refs = make(map[int]interface{})
+	}
+	ref := len(s.refs) + 1
+	s.refs[ref] = v
+	return ref
+}
+
+// display formats v for the client. Strings are quoted.
+func display(v valuer.Valuer) string {
+	if str, ok := v.(*valuer.String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return v.String()
+}
+
+// output sends text written by the program as output events.
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.s.event("output", OutputEvent{Category: o.category, Output: string(p)})
+	return len(p), nil
+}
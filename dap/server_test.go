@@ -0,0 +1,346 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const program = `fun add(a, b) {
+    var sum = a + b;
+    return sum;
+}
+var total = 0;
+for (var i = 0; i < 3; i = i + 1) {
+    total = add(total, i);
+}
+print total;
+class P { init(x) { this.x = x; } }
+var p = P(1);
+print p.x;
+`
+
+type testMessage struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type client struct {
+	t        *testing.T
+	w        io.WriteCloser
+	messages chan testMessage
+	seq      int
+	// events are received events which are not waited yet.
+	events []testMessage
+	// log has all received events.
+	log  []testMessage
+	done chan error
+}
+
+func newClient(t *testing.T) *client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, w: inW, messages: make(chan testMessage, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(inR, outW).Serve()
+		outW.Close()
+	}()
+	go func() {
+		r := bufio.NewReader(outR)
+		for {
+			b, err := readMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg testMessage
+			json.Unmarshal(b, &msg)
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) next() testMessage {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed connection")
+		}
+		if msg.Type == "event" {
+			c.log = append(c.log, msg)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timeout waiting for message")
+	}
+	return testMessage{}
+}
+
+// request sends a request and returns its response. body is decoded into v if v is not nil.
+func (c *client) request(command string, args interface{}, v interface{}) testMessage {
+	c.seq++
+	req := map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": args}
+	if err := writeMessage(c.w, req); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg.RequestSeq != c.seq {
+			c.t.Fatalf("unexpected response %+v", msg)
+		}
+		if v != nil {
+			if !msg.Success {
+				c.t.Fatalf("%s failed. error: %s", command, msg.Message)
+			}
+			if err := json.Unmarshal(msg.Body, v); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return msg
+	}
+}
+
+// wait returns the next event named name, skipping other events.
+func (c *client) wait(name string) testMessage {
+	for len(c.events) > 0 {
+		msg := c.events[0]
+		c.events = c.events[1:]
+		if msg.Event == name {
+			return msg
+		}
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "event" && msg.Event == name {
+			return msg
+		}
+	}
+}
+
+// waitStopped waits for a stopped event and returns its reason and line of the top frame.
+func (c *client) waitStopped() (string, int, string) {
+	var stopped StoppedEvent
+	json.Unmarshal(c.wait("stopped").Body, &stopped)
+	var trace struct{ StackFrames []StackFrame }
+	c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+	top := trace.StackFrames[0]
+	return stopped.Reason, top.Line, top.Name
+}
+
+func (c *client) evaluate(expr string, frame int) string {
+	var result struct{ Result string }
+	c.request("evaluate", EvaluateArguments{Expression: expr, FrameID: frame}, &result)
+	return result.Result
+}
+
+func writeProgram(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "main.lox")
+	if err := ioutil.WriteFile(file, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestDebugSession(t *testing.T) {
+	file := writeProgram(t)
+	defer os.RemoveAll(filepath.Dir(file))
+	c := newClient(t)
+
+	var caps Capabilities
+	c.request("initialize", map[string]interface{}{"adapterID": "lox"}, &caps)
+	if !caps.SupportsConditionalBreakpoints {
+		t.Errorf("conditional breakpoints should be supported")
+	}
+	c.wait("initialized")
+	c.request("launch", LaunchArguments{Program: file}, nil)
+	var bps struct{ Breakpoints []Breakpoint }
+	c.request("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: file},
+		Breakpoints: []SourceBreakpoint{{Line: 7, Condition: "i == 2"}, {Line: 9, Condition: "+"}},
+	}, &bps)
+	if len(bps.Breakpoints) != 2 || !bps.Breakpoints[0].Verified || bps.Breakpoints[1].Verified {
+		t.Errorf("unexpected breakpoints %+v", bps.Breakpoints)
+	}
+	c.request("configurationDone", nil, nil)
+
+	steps := []struct {
+		command string
+		reason  string
+		line    int
+		frame   string
+	}{
+		{"", "breakpoint", 7, "<script>"},
+		{"stepIn", "step", 2, "add"},
+		{"next", "step", 3, "add"},
+		{"stepOut", "step", 9, "<script>"},
+		{"next", "step", 10, "<script>"},
+		{"next", "step", 11, "<script>"},
+		{"next", "step", 12, "<script>"},
+	}
+	for i, step := range steps {
+		if step.command != "" {
+			c.request(step.command, map[string]interface{}{"threadId": threadID}, nil)
+		}
+		reason, line, frame := c.waitStopped()
+		if reason != step.reason || line != step.line || frame != step.frame {
+			t.Fatalf("step [%d]: expected %s at %s:%d. got %s at %s:%d", i, step.reason, step.frame, step.line, reason, frame, line)
+		}
+		switch i {
+		case 0:
+			if got := c.evaluate("total", 0); got != "1" {
+				t.Errorf("expected total is 1. got %s", got)
+			}
+			var scopes struct{ Scopes []Scope }
+			c.request("scopes", ScopesArguments{FrameID: 0}, &scopes)
+			var names []string
+			for _, scope := range scopes.Scopes {
+				names = append(names, scope.Name)
+			}
+			if strings.Join(names, " ") != "Locals Enclosing Globals" {
+				t.Fatalf("unexpected scopes %v", names)
+			}
+			var vars struct{ Variables []Variable }
+			c.request("variables", VariablesArguments{VariablesReference: scopes.Scopes[1].VariablesReference}, &vars)
+			if len(vars.Variables) != 1 || vars.Variables[0].Name != "i" || vars.Variables[0].Value != "2" {
+				t.Errorf("unexpected variables %+v", vars.Variables)
+			}
+		case 2:
+			if got := c.evaluate("sum * 10", 0); got != "30" {
+				t.Errorf("expected sum * 10 is 30. got %s", got)
+			}
+			if got := c.evaluate("i", 1); got != "2" {
+				t.Errorf("expected i in caller frame is 2. got %s", got)
+			}
+			if msg := c.request("evaluate", EvaluateArguments{Expression: "nothing", FrameID: 0}, nil); msg.Success {
+				t.Errorf("evaluating undefined variable should fail")
+			}
+		case 6:
+			var result struct {
+				Result             string
+				VariablesReference int
+			}
+			c.request("evaluate", EvaluateArguments{Expression: "p"}, &result)
+			var vars struct{ Variables []Variable }
+			c.request("variables", VariablesArguments{VariablesReference: result.VariablesReference}, &vars)
+			if len(vars.Variables) != 1 || vars.Variables[0].Name != "x" || vars.Variables[0].Value != "1" {
+				t.Errorf("unexpected fields %+v", vars.Variables)
+			}
+		}
+	}
+
+	c.request("continue", map[string]interface{}{"threadId": threadID}, nil)
+	c.wait("terminated")
+	var outputs []string
+	for _, e := range c.log {
+		if e.Event == "output" {
+			var o OutputEvent
+			json.Unmarshal(e.Body, &o)
+			outputs = append(outputs, o.Output)
+		}
+	}
+	if strings.Join(outputs, "") != "3\n1\n" {
+		t.Errorf("unexpected output %q", outputs)
+	}
+	c.request("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("serve failed. error: %s", err.Error())
+	}
+}
+
+func TestStopOnEntryAndDisconnect(t *testing.T) {
+	file := writeProgram(t)
+	defer os.RemoveAll(filepath.Dir(file))
+	c := newClient(t)
+
+	c.request("initialize", nil, nil)
+	c.request("launch", LaunchArguments{Program: file, StopOnEntry: true}, nil)
+	c.request("configurationDone", nil, nil)
+	reason, line, _ := c.waitStopped()
+	if reason != "entry" || line != 1 {
+		t.Errorf("expected to stop on entry at line 1. got %s at %d", reason, line)
+	}
+	c.request("disconnect", nil, nil)
+	c.wait("terminated")
+	if err := <-c.done; err != nil {
+		t.Errorf("serve failed. error: %s", err.Error())
+	}
+}
+
+func TestModuleSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"main.lox": "import \"lib\" as lib;\nprint lib.twice(2);\n",
+		"lib.lox":  "fun twice(x) {\n  return x * 2;\n}\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main, lib := filepath.Join(dir, "main.lox"), filepath.Join(dir, "lib.lox")
+	c := newClient(t)
+
+	c.request("initialize", nil, nil)
+	c.request("launch", LaunchArguments{Program: main}, nil)
+	// breakpoints are set per source, and line 2 of both files has one.
+	for _, file := range []string{main, lib} {
+		c.request("setBreakpoints", SetBreakpointsArguments{
+			Source:      Source{Path: file},
+			Breakpoints: []SourceBreakpoint{{Line: 2}},
+		}, nil)
+	}
+	c.request("configurationDone", nil, nil)
+
+	expected := [][]string{
+		{"<script>", main},
+		{"lib.twice", lib, "<script>", main},
+	}
+	for i, frames := range expected {
+		if i > 0 {
+			c.request("continue", map[string]interface{}{"threadId": threadID}, nil)
+		}
+		c.wait("stopped")
+		var trace struct{ StackFrames []StackFrame }
+		c.request("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace)
+		var got []string
+		for _, f := range trace.StackFrames {
+			if f.Line != 2 {
+				t.Errorf("stop [%d]: expected frames at line 2. got %+v", i, f)
+			}
+			got = append(got, f.Name, f.Source.Path)
+		}
+		if strings.Join(got, " ") != strings.Join(frames, " ") {
+			t.Errorf("stop [%d]: expected frames %v. got %v", i, frames, got)
+		}
+	}
+	c.request("disconnect", nil, nil)
+	c.wait("terminated")
+	if err := <-c.done; err != nil {
+		t.Errorf("serve failed. error: %s", err.Error())
+	}
+}
@@ -0,0 +1,450 @@
+// Package debug implements breakpoints and stepping for Lox programs on top of
+// the statement hook of the interpreter.
+//
+// A program runs on its own goroutine by Run. When it pauses, the goroutine blocks
+// until the debugger is resumed, and frames can be inspected from other goroutines.
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// Reasons of stops.
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+	ReasonWatch      = "watch"
+)
+
+var errNotPaused = errors.New("program is not paused")
+
+// errTerminated is panicked in the program goroutine to terminate the program.
+var errTerminated = errors.New("program is terminated")
+
+type mode int
+
+const (
+	modeRun mode = iota
+	modePause
+	modeStepIn
+	modeStepOver
+	modeStepOut
+	modeTerminate
+)
+
+// Breakpoint is a line breakpoint. It stops the program only when its condition is truthy, if any.
+type Breakpoint struct {
+	// File is the absolute name of the file, empty for a program which isn't read from a file.
+	File      string
+	Line      int
+	Condition string
+
+	cond ast.Expr
+}
+
+// location is a line of a file.
+type location struct {
+	file string
+	line int
+}
+
+// Watch is an expression whose value is watched. The program stops when the
+// value changes to a defined one.
+type Watch struct {
+	Expr string
+
+	expr    ast.Expr
+	value   string
+	defined bool
+}
+
+// Stop describes where and why the program pauses.
+type Stop struct {
+	Reason string
+	// File is the absolute name of the file, empty for a program which isn't read from a file.
+	File string
+	Pos  token.Position
+	// Watch is the watch whose value changed from Old to New, if Reason is ReasonWatch.
+	Watch    *Watch
+	Old, New string
+}
+
+// Scope is an environment visible from a frame.
+type Scope struct {
+	Name string
+	Env  *valuer.Environment
+}
+
+// Debugger controls execution of a program.
+type Debugger struct {
+	// OnStop is called on the program goroutine when the program pauses.
+	OnStop func(stop Stop)
+
+	mu          sync.Mutex
+	breakpoints map[location]*Breakpoint
+	watches     []*Watch
+	mode        mode
+	pauseReason string
+	// depth is the call depth when the program is resumed by stepping.
+	depth      int
+	paused     bool
+	evaluating bool
+	terminated bool
+	resume     chan mode
+}
+
+// New returns a debugger without breakpoints.
+func New() *Debugger {
+	return &Debugger{
+		breakpoints: make(map[location]*Breakpoint),
+		resume:      make(chan mode),
+	}
+}
+
+// SetBreakpoint sets a breakpoint at line of file, replacing the existing one. An
+// empty file is a program which isn't read from a file. An empty condition makes
+// the breakpoint unconditional.
+func (d *Debugger) SetBreakpoint(file string, line int, condition string) (*Breakpoint, error) {
+	bp := &Breakpoint{File: absFile(file), Line: line, Condition: condition}
+	if condition != "" {
+		expr, err := parser.ParseExpr(condition)
+		if err != nil {
+			return nil, err
+		}
+		bp.cond = expr
+	}
+	d.mu.Lock()
+	d.breakpoints[location{bp.File, line}] = bp
+	d.mu.Unlock()
+	return bp, nil
+}
+
+// ClearBreakpoint removes the breakpoint at line of file. It reports whether there was one.
+func (d *Debugger) ClearBreakpoint(file string, line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	l := location{absFile(file), line}
+	_, ok := d.breakpoints[l]
+	delete(d.breakpoints, l)
+	return ok
+}
+
+// ClearBreakpoints removes all breakpoints in file.
+func (d *Debugger) ClearBreakpoints(file string) {
+	file = absFile(file)
+	d.mu.Lock()
+	for l := range d.breakpoints {
+		if l.file == file {
+			delete(d.breakpoints, l)
+		}
+	}
+	d.mu.Unlock()
+}
+
+// Breakpoints returns all breakpoints sorted by file and line.
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var bps []*Breakpoint
+	for _, bp := range d.breakpoints {
+		bps = append(bps, bp)
+	}
+	sort.Slice(bps, func(i, j int) bool {
+		if bps[i].File != bps[j].File {
+			return bps[i].File < bps[j].File
+		}
+		return bps[i].Line < bps[j].Line
+	})
+	return bps
+}
+
+// absFile returns the absolute name of file, which frames of the interpreter are labeled with.
+func absFile(file string) string {
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			return abs
+		}
+	}
+	return file
+}
+
+// AddWatch watches the value of an expression.
+func (d *Debugger) AddWatch(src string) (*Watch, error) {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watch{Expr: src, expr: expr}
+	d.mu.Lock()
+	d.watches = append(d.watches, w)
+	d.mu.Unlock()
+	return w, nil
+}
+
+// Watches returns all watches.
+func (d *Debugger) Watches() []*Watch {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*Watch(nil), d.watches...)
+}
+
+// Run executes statements under control of the debugger, and returns when the
+// program finishes or is terminated. Runtime errors are returned.
+func (d *Debugger) Run(statements []ast.Stmt, stopOnEntry bool) (err error) {
+	d.mu.Lock()
+	d.mode, d.terminated = modeRun, false
+	if stopOnEntry {
+		d.mode, d.pauseReason = modePause, ReasonEntry
+	}
+	d.mu.Unlock()
+
+	interpreter.SetHook(d.hook)
+	defer interpreter.SetHook(nil)
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errTerminated {
+				panic(r)
+			}
+			err = nil
+		}
+	}()
+	return interpreter.Execute(statements)
+}
+
+func (d *Debugger) hook(stmt ast.Stmt) {
+	d.mu.Lock()
+	if d.evaluating {
+		d.mu.Unlock()
+		return
+	}
+	if d.terminated {
+		d.mu.Unlock()
+		panic(errTerminated)
+	}
+	depth := interpreter.Depth()
+	reason := ""
+	switch d.mode {
+	case modePause:
+		reason = d.pauseReason
+	case modeStepIn:
+		reason = ReasonStep
+	case modeStepOver:
+		if depth <= d.depth {
+			reason = ReasonStep
+		}
+	case modeStepOut:
+		if depth < d.depth {
+			reason = ReasonStep
+		}
+	}
+	file := interpreter.CurrentFrame().File
+	bp := d.breakpoints[location{file, stmt.Pos().Line}]
+	watches := d.watches
+	d.mu.Unlock()
+
+	stop := Stop{Reason: reason, File: file, Pos: stmt.Pos()}
+	if len(watches) != 0 {
+		// watches are checked even when stopping for another reason to keep their values current.
+		if w, old := d.changed(watches); w != nil && reason == "" {
+			stop.Reason, stop.Watch, stop.Old, stop.New = ReasonWatch, w, old, w.value
+		}
+	}
+	if stop.Reason == "" && bp != nil && d.hit(bp) {
+		stop.Reason = ReasonBreakpoint
+	}
+	if stop.Reason != "" {
+		d.stop(stop)
+	}
+}
+
+// changed evaluates watches in the current frame, and returns the first one whose
+// value changed to a defined one, along with its old value.
+func (d *Debugger) changed(watches []*Watch) (*Watch, string) {
+	d.setEvaluating(true)
+	defer d.setEvaluating(false)
+	env := interpreter.Frames()[0].Env
+	var changed *Watch
+	var old string
+	for _, w := range watches {
+		v, err := interpreter.EvaluateIn(w.expr, env)
+		if err != nil {
+			w.defined = false
+			continue
+		}
+		value := v.String()
+		if changed == nil && (!w.defined || value != w.value) {
+			changed, old = w, w.value
+			if !w.defined {
+				old = "<undefined>"
+			}
+		}
+		w.value, w.defined = value, true
+	}
+	return changed, old
+}
+
+// hit reports whether the condition of bp holds in the current frame.
+// A condition failing to evaluate is regarded as true.
+func (d *Debugger) hit(bp *Breakpoint) bool {
+	if bp.cond == nil {
+		return true
+	}
+	d.setEvaluating(true)
+	defer d.setEvaluating(false)
+	v, err := interpreter.EvaluateIn(bp.cond, interpreter.Frames()[0].Env)
+	return err != nil || valuer.IsTruthy(v)
+}
+
+// stop blocks the program goroutine until the debugger is resumed. The program is
+// terminated instead if Terminate was called after the hook checked it, since
+// Terminate sends nothing to a program which isn't paused.
+func (d *Debugger) stop(stop Stop) {
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		panic(errTerminated)
+	}
+	d.paused, d.mode = true, modeRun
+	d.mu.Unlock()
+	if d.OnStop != nil {
+		d.OnStop(stop)
+	}
+
+	m := <-d.resume
+	d.mu.Lock()
+	d.paused, d.mode, d.depth = false, m, interpreter.Depth()
+	d.mu.Unlock()
+	if m == modeTerminate {
+		panic(errTerminated)
+	}
+}
+
+func (d *Debugger) setEvaluating(b bool) {
+	d.mu.Lock()
+	d.evaluating = b
+	d.mu.Unlock()
+}
+
+// Paused reports whether the program is paused.
+func (d *Debugger) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+func (d *Debugger) cont(m mode) error {
+	if !d.Paused() {
+		return errNotPaused
+	}
+	d.resume <- m
+	return nil
+}
+
+// Continue resumes the program until a breakpoint is hit.
+func (d *Debugger) Continue() error { return d.cont(modeRun) }
+
+// StepIn resumes the program until the next statement.
+func (d *Debugger) StepIn() error { return d.cont(modeStepIn) }
+
+// StepOver resumes the program until the next statement of the current or a calling function.
+func (d *Debugger) StepOver() error { return d.cont(modeStepOver) }
+
+// StepOut resumes the program until the current function returns.
+func (d *Debugger) StepOut() error { return d.cont(modeStepOut) }
+
+// Pause pauses the running program before its next statement.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	if !d.paused {
+		d.mode, d.pauseReason = modePause, ReasonPause
+	}
+	d.mu.Unlock()
+}
+
+// Terminate stops the program. Run returns after the program goroutine unwinds.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	d.terminated = true
+	paused := d.paused
+	d.mu.Unlock()
+	if paused {
+		d.resume <- modeTerminate
+	}
+}
+
+// Frames returns the call stack of the paused program, the innermost frame first.
+func (d *Debugger) Frames() ([]interpreter.Frame, error) {
+	if !d.Paused() {
+		return nil, errNotPaused
+	}
+	return interpreter.Frames(), nil
+}
+
+func (d *Debugger) frame(i int) (interpreter.Frame, error) {
+	frames, err := d.Frames()
+	if err != nil {
+		return interpreter.Frame{}, err
+	}
+	if i < 0 || i >= len(frames) {
+		return interpreter.Frame{}, fmt.Errorf("no frame %d", i)
+	}
+	return frames[i], nil
+}
+
+// Scopes returns environments visible from frame i, from the innermost to the global one.
+func (d *Debugger) Scopes(i int) ([]Scope, error) {
+	f, err := d.frame(i)
+	if err != nil {
+		return nil, err
+	}
+	var scopes []Scope
+	// The root environment holds builtins, which encloses the globals of every module.
+	for env := f.Env; env != nil && env.Enclosing != nil; env = env.Enclosing {
+		name := "Enclosing"
+		switch {
+		case env.Enclosing.Enclosing == nil:
+			name = "Globals"
+		case len(scopes) == 0:
+			name = "Locals"
+		}
+		scopes = append(scopes, Scope{Name: name, Env: env})
+	}
+	return scopes, nil
+}
+
+// Evaluate evaluates an expression in frame i of the paused program.
+func (d *Debugger) Evaluate(src string, i int) (valuer.Valuer, error) {
+	f, err := d.frame(i)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	d.setEvaluating(true)
+	defer d.setEvaluating(false)
+	return interpreter.EvaluateIn(expr, f.Env)
+}
+
+// Names returns names defined in env, sorted.
+func Names(env *valuer.Environment) []string {
+	names := make([]string, 0, len(env.Values))
+	for name := range env.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
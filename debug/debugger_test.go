@@ -0,0 +1,201 @@
+package debug
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+const program = `fun add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+var total = 0;
+for (var i = 0; i < 3; i = i + 1) {
+  total = add(total, i);
+}
+print total;
+`
+
+// session runs program under d, and returns a channel receiving stops, which is
+// closed when the program finishes.
+func session(t *testing.T, d *Debugger) <-chan Stop {
+	statements, err := parser.ParseStmts(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stops := make(chan Stop)
+	d.OnStop = func(stop Stop) {
+		stops <- stop
+	}
+	interpreter.Reset()
+	interpreter.SetOutput(&bytes.Buffer{})
+	go func() {
+		defer close(stops)
+		defer interpreter.SetOutput(nil)
+		if err := d.Run(statements, false); err != nil {
+			t.Error(err)
+		}
+	}()
+	return stops
+}
+
+func TestStepping(t *testing.T) {
+	d := New()
+	if _, err := d.SetBreakpoint("", 2, "b == 1"); err != nil {
+		t.Fatal(err)
+	}
+	stops := session(t, d)
+
+	tests := []struct {
+		resume   func() error
+		reason   string
+		line     int
+		function string
+	}{
+		{nil, ReasonBreakpoint, 2, "add"},
+		{d.StepOver, ReasonStep, 3, "add"},
+		{d.StepOut, ReasonStep, 6, "<script>"},
+		{d.StepIn, ReasonStep, 7, "<script>"},
+		{d.StepIn, ReasonStep, 2, "add"},
+	}
+	for i, tt := range tests {
+		if tt.resume != nil {
+			if err := tt.resume(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stop, ok := <-stops
+		if !ok {
+			t.Fatalf("tests[%d] - program finished", i)
+		}
+		frames, _ := d.Frames()
+		if stop.Reason != tt.reason || stop.Pos.Line != tt.line || frames[0].Function != tt.function {
+			t.Errorf("tests[%d] - stop wrong. expected=%s at %s:%d, got=%s at %s:%d",
+				i, tt.reason, tt.function, tt.line, stop.Reason, frames[0].Function, stop.Pos.Line)
+		}
+	}
+	if v, err := d.Evaluate("a + b", 0); err != nil || v.String() != "3" {
+		t.Errorf("a + b wrong. got=%v, %v", v, err)
+	}
+	d.Terminate()
+	for range stops {
+	}
+}
+
+func TestWatch(t *testing.T) {
+	d := New()
+	if _, err := d.AddWatch("total"); err != nil {
+		t.Fatal(err)
+	}
+	stops := session(t, d)
+
+	expected := [][2]string{{"<undefined>", "0"}, {"0", "1"}, {"1", "3"}}
+	i := 0
+	for stop := range stops {
+		if i >= len(expected) {
+			t.Fatalf("unexpected stop at line %d", stop.Pos.Line)
+		}
+		if stop.Reason != ReasonWatch || stop.Watch.Expr != "total" || stop.Old != expected[i][0] || stop.New != expected[i][1] {
+			t.Errorf("stops[%d] wrong. expected=%s -> %s, got=%s %s -> %s",
+				i, expected[i][0], expected[i][1], stop.Reason, stop.Old, stop.New)
+		}
+		i++
+		d.Continue()
+	}
+	if i != len(expected) {
+		t.Errorf("wrong number of stops. expected=%d, got=%d", len(expected), i)
+	}
+}
+
+func TestModuleBreakpoint(t *testing.T) {
+	// line 7 of the module squares x, and line 7 of the program is its last line.
+	statements, err := parser.ParseStmts(`import "math" as m;
+var a = 1;
+var b = 2;
+var c = 3;
+var d = 4;
+var e = 5;
+print m.square(e);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	module, err := filepath.Abs("../interpreter/testdata/modules/math.lox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New()
+	if _, err := d.SetBreakpoint("../interpreter/testdata/modules/math.lox", 7, ""); err != nil {
+		t.Fatal(err)
+	}
+	stops := make(chan Stop, 1)
+	d.OnStop = func(stop Stop) {
+		stops <- stop
+	}
+	interpreter.Reset()
+	interpreter.SetFile("../interpreter/testdata/modules/main.lox")
+	defer interpreter.SetFile("")
+	interpreter.SetOutput(&bytes.Buffer{})
+	defer interpreter.SetOutput(nil)
+	done := make(chan error)
+	go func() {
+		done <- d.Run(statements, false)
+	}()
+
+	stop := <-stops
+	frames, _ := d.Frames()
+	if stop.File != module || stop.Pos.Line != 7 || frames[0].File != module || frames[0].Function != "math.square" {
+		t.Errorf("stop wrong. expected=math.square at %s:7, got=%s at %s:%d", module, frames[0].Function, stop.File, stop.Pos.Line)
+	}
+	if main := frames[1].File; filepath.Base(main) != "main.lox" || frames[1].Pos.Line != 7 {
+		t.Errorf("caller frame wrong. got=%s:%d", main, frames[1].Pos.Line)
+	}
+	d.Continue()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTerminateInHook(t *testing.T) {
+	statements, err := parser.ParseStmts(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := New()
+	d.OnStop = func(stop Stop) {
+		t.Errorf("unexpected stop at line %d", stop.Pos.Line)
+	}
+	// the condition terminates the program after the hook checks for termination
+	// and before the program pauses.
+	if _, err := d.SetBreakpoint("", 2, "terminate()"); err != nil {
+		t.Fatal(err)
+	}
+	interpreter.Reset()
+	interpreter.Define("terminate", &valuer.NativeFunction{
+		Name: "terminate",
+		Fn: func(args []valuer.Valuer) (valuer.Valuer, error) {
+			d.Terminate()
+			return &valuer.Boolean{Value: true}, nil
+		},
+	})
+	interpreter.SetOutput(&bytes.Buffer{})
+	defer interpreter.SetOutput(nil)
+	done := make(chan error)
+	go func() {
+		done <- d.Run(statements, false)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("terminated program returns error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("program is not terminated")
+	}
+}
@@ -7,13 +7,39 @@ import (
 type RuntimeError struct {
 	s     string
 	token token.Token
+	pos   token.Position
 }
 
 func (r *RuntimeError) Error() string {
+	if r.pos.IsValid() {
+		return r.pos.String() + " " + r.s
+	}
 	return r.s
 }
 
+// Pos returns position where the error occurs. It is invalid if the position is unknown.
+func (r *RuntimeError) Pos() token.Position {
+	return r.pos
+}
+
+// Message returns the error message without position.
+func (r *RuntimeError) Message() string {
+	return r.s
+}
+
+// At returns a copy of the error occurring at pos.
+func (r *RuntimeError) At(pos token.Position) RuntimeError {
+	e := *r
+	e.pos = pos
+	return e
+}
+
 // Error throws runtime error.
 func Error(token token.Token, s string) {
 	panic(RuntimeError{token: token, s: s})
 }
+
+// ErrorAt throws runtime error occurring at pos.
+func ErrorAt(pos token.Position, token token.Token, s string) {
+	panic(RuntimeError{token: token, s: s, pos: pos})
+}
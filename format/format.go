@@ -0,0 +1,359 @@
+// Package format implements canonical formatting of Lox source code.
+package format
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/token"
+)
+
+const indent = "    "
+
+// Source formats Lox source code. Comments are preserved.
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	statements, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Fprint(&buf, statements, p.Comments()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Fprint pretty-prints statements along with comments to w.
+// Comments must be sorted by position, as returned by the parser.
+func Fprint(w io.Writer, statements []ast.Stmt, comments []*ast.Comment) error {
+	p := &printer{comments: comments}
+	p.stmtList(statements, token.Position{})
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// Node pretty-prints a single node to w without comments.
+func Node(w io.Writer, node ast.Node) error {
+	p := &printer{}
+	switch n := node.(type) {
+	case ast.Stmt:
+		p.stmt(n)
+	case ast.Expr:
+		p.expr(n)
+	case *ast.Ident:
+		p.print(n.Name)
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+	// atLineStart reports whether indentation should be written before next output.
+	atLineStart bool
+
+	comments []*ast.Comment
+	// next is the index of the next comment to print.
+	next int
+	// lastLine is source line of the last printed statement or comment.
+	lastLine int
+}
+
+func (p *printer) print(args ...string) {
+	for _, s := range args {
+		if s == "" {
+			continue
+		}
+		if p.atLineStart {
+			p.buf.WriteString(strings.Repeat(indent, p.indent))
+			p.atLineStart = false
+		}
+		p.buf.WriteString(s)
+	}
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+	p.atLineStart = true
+}
+
+// hasCommentBefore reports whether there is a comment before pos.
+// An invalid pos stands for the end of file.
+func (p *printer) hasCommentBefore(pos token.Position) bool {
+	if p.next >= len(p.comments) {
+		return false
+	}
+	return !pos.IsValid() || p.comments[p.next].Slash.Offset < pos.Offset
+}
+
+// blankLine keeps a single blank line before source line when there is one in source.
+func (p *printer) blankLine(line int, first bool) {
+	if !first && p.lastLine > 0 && line > p.lastLine+1 {
+		p.newline()
+	}
+}
+
+// leadingComments prints comments before pos on their own lines.
+func (p *printer) leadingComments(pos token.Position, first bool) bool {
+	for p.hasCommentBefore(pos) {
+		c := p.comments[p.next]
+		p.blankLine(c.Slash.Line, first)
+		p.print(c.Text)
+		p.newline()
+		p.lastLine = c.Slash.Line
+		p.next++
+		first = false
+	}
+	return first
+}
+
+// trailingComment prints a comment on line after the current output.
+func (p *printer) trailingComment(line int) {
+	if p.next < len(p.comments) && p.comments[p.next].Slash.Line == line {
+		p.print(" ", p.comments[p.next].Text)
+		p.next++
+	}
+}
+
+// stmtList prints statements of a file or a block, one statement per line.
+// Comments before end are printed too.
+func (p *printer) stmtList(statements []ast.Stmt, end token.Position) {
+	first := true
+	for _, stmt := range statements {
+		first = p.leadingComments(stmt.Pos(), first)
+		p.blankLine(stmt.Pos().Line, first)
+		p.stmt(stmt)
+		p.trailingComment(stmt.End().Line)
+		p.newline()
+		p.lastLine = stmt.End().Line
+		// comments inside the statement which are not attached to nested statements.
+		p.leadingComments(stmt.End(), true)
+		first = false
+	}
+	p.leadingComments(end, first)
+}
+
+// block prints statements in braces.
+func (p *printer) block(lbrace token.Position, statements []ast.Stmt, rbrace token.Position) {
+	if len(statements) == 0 && !p.hasCommentBefore(rbrace) {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.trailingComment(lbrace.Line)
+	p.newline()
+	p.indent++
+	p.lastLine = lbrace.Line
+	p.stmtList(statements, rbrace)
+	p.indent--
+	p.print("}")
+}
+
+// body prints body of if, while and for statements.
+// A block stays on the same line, other statements are indented on the next line.
+func (p *printer) body(stmt ast.Stmt) {
+	if block, ok := stmt.(*ast.BlockStmt); ok {
+		p.print(" ")
+		p.block(block.Lbrace, block.Statements, block.Rbrace)
+		return
+	}
+	p.newline()
+	p.indent++
+	p.stmt(stmt)
+	p.indent--
+}
+
+func (p *printer) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		p.block(s.Lbrace, s.Statements, s.Rbrace)
+	case *ast.ClassStmt:
+		p.print("class ", s.Name)
+		if s.SuperClass.Name != "" {
+			p.print(" < ", s.SuperClass.Name)
+		}
+		p.print(" ")
+		methods := make([]ast.Stmt, len(s.Methods))
+		for i, method := range s.Methods {
+			methods[i] = method
+		}
+		p.block(s.NamePos, methods, s.Rbrace)
+	case *ast.ExprStmt:
+		p.expr(s.Expression)
+		p.print(";")
+	case *ast.ForStmt:
+		p.print("for (")
+		switch init := s.Initializer.(type) {
+		case nil:
+			p.print(";")
+		default:
+			p.stmt(init)
+		}
+		if s.Condition != nil {
+			p.print(" ")
+			p.expr(s.Condition)
+		}
+		p.print(";")
+		if s.Increment != nil {
+			p.print(" ")
+			p.expr(s.Increment)
+		}
+		p.print(")")
+		p.body(s.Body)
+	case *ast.FunctionStmt:
+		if s.FunPos != s.NamePos {
+			p.print("fun ")
+		}
+		p.print(s.Name, "(")
+		for i, param := range s.Params {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(param.Name)
+		}
+		p.print(") ")
+		p.block(s.NamePos, s.Body, s.Rbrace)
+	case *ast.IfStmt:
+		p.print("if (")
+		p.expr(s.Condition)
+		p.print(")")
+		p.body(s.ThenBranch)
+		if s.ElseBranch == nil {
+			return
+		}
+		if _, ok := s.ThenBranch.(*ast.BlockStmt); ok {
+			p.print(" ")
+		} else {
+			p.newline()
+		}
+		p.print("else")
+		if elseIf, ok := s.ElseBranch.(*ast.IfStmt); ok {
+			p.print(" ")
+			p.stmt(elseIf)
+		} else {
+			p.body(s.ElseBranch)
+		}
+	case *ast.ImportStmt:
+		path := &ast.Literal{Token: token.String, Value: s.Path}
+		if len(s.Names) == 0 {
+			p.print("import ")
+			p.literal(path)
+			p.print(" as ", s.Name.Name, ";")
+			return
+		}
+		p.print("from ")
+		p.literal(path)
+		p.print(" import ")
+		for i, name := range s.Names {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(name.Name)
+		}
+		p.print(";")
+	case *ast.PrintStmt:
+		p.print("print ")
+		p.expr(s.Expression)
+		p.print(";")
+	case *ast.ReturnStmt:
+		p.print("return")
+		if s.Value != nil {
+			p.print(" ")
+			p.expr(s.Value)
+		}
+		p.print(";")
+	case *ast.VarStmt:
+		p.print("var ", s.Name.Name)
+		if s.Initializer != nil {
+			p.print(" = ")
+			p.expr(s.Initializer)
+		}
+		p.print(";")
+	case *ast.WhileStmt:
+		p.print("while (")
+		p.expr(s.Condition)
+		p.print(")")
+		p.body(s.Body)
+	}
+}
+
+func (p *printer) expr(expr ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.AssignExpr:
+		p.print(e.Left.Name, " = ")
+		p.expr(e.Value)
+	case *ast.BinaryExpr:
+		p.expr(e.Left)
+		p.print(" ", e.Operator.String(), " ")
+		p.expr(e.Right)
+	case *ast.CallExpr:
+		p.expr(e.Callee)
+		p.print("(")
+		for i, arg := range e.Arguments {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expr(arg)
+		}
+		p.print(")")
+	case *ast.GetExpr:
+		p.expr(e.Object)
+		p.print(".", e.Name)
+	case *ast.GroupingExpr:
+		p.print("(")
+		p.expr(e.Expression)
+		p.print(")")
+	case *ast.InterpolationExpr:
+		p.print(`"`)
+		for i, str := range e.Strings {
+			p.print(escape(str))
+			if i < len(e.Exprs) {
+				p.print("${")
+				p.expr(e.Exprs[i])
+				p.print("}")
+			}
+		}
+		p.print(`"`)
+	case *ast.Literal:
+		p.literal(e)
+	case *ast.LogicalExpr:
+		p.expr(e.Left)
+		p.print(" ", e.Operator.String(), " ")
+		p.expr(e.Right)
+	case *ast.SetExpr:
+		p.expr(e.Object)
+		p.print(".", e.Name, " = ")
+		p.expr(e.Value)
+	case *ast.SuperExpr:
+		p.print("super.", e.Method)
+	case *ast.ThisExpr:
+		p.print("this")
+	case *ast.UnaryExpr:
+		p.print(e.Operator.String())
+		p.expr(e.Right)
+	case *ast.VariableExpr:
+		p.print(e.Name)
+	}
+}
+
+func (p *printer) literal(lit *ast.Literal) {
+	switch lit.Token {
+	case token.String:
+		p.print(`"`, escape(lit.Value), `"`)
+	case token.Number:
+		p.print(lit.Value)
+	default:
+		p.print(lit.Token.String())
+	}
+}
+
+// escape escapes quotes and "${" in s, which is a string or a part of it.
+func escape(s string) string {
+	return strings.NewReplacer(`"`, `\"`, "${", `\${`).Replace(s)
+}
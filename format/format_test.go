@@ -0,0 +1,120 @@
+package format
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a=1;var b;", "var a = 1;\nvar b;\n"},
+		{"print -a+!b*(c-1);", "print -a + !b * (c - 1);\n"},
+		{`print "say \"hi\"";`, "print \"say \\\"hi\\\"\";\n"},
+		{`print "${a+1} \${b}"+"$";`, "print \"${a + 1} \\${b}\" + \"$\";\n"},
+		{`print "${"${x}"}";`, "print \"${\"${x}\"}\";\n"},
+		{"print a and b or nil;", "print a and b or nil;\n"},
+		{"x.y.z=f(1,2)(3);", "x.y.z = f(1, 2)(3);\n"},
+		{"fun f(a,b){return;}", "fun f(a, b) {\n    return;\n}\n"},
+		{"fun f(){}", "fun f() {}\n"},
+		{"class A{init(x){this.x=x;}}", "class A {\n    init(x) {\n        this.x = x;\n    }\n}\n"},
+		{"if(a)print a;else print b;", "if (a)\n    print a;\nelse\n    print b;\n"},
+		{"if(a){}else if(b){print b;}", "if (a) {} else if (b) {\n    print b;\n}\n"},
+		{"while(true){a=a+1;}", "while (true) {\n    a = a + 1;\n}\n"},
+		{"for(var i=0;i<3;i=i+1){}", "for (var i = 0; i < 3; i = i + 1) {}\n"},
+		{"for(;;)print 1;", "for (;;)\n    print 1;\n"},
+		{"for(i=0;;){}", "for (i = 0;;) {}\n"},
+		{"{{print 1;}}", "{\n    {\n        print 1;\n    }\n}\n"},
+		{`import "lib/math"as m;from "lib/math"import a,b;`, "import \"lib/math\" as m;\nfrom \"lib/math\" import a, b;\n"},
+	}
+
+	for i, test := range tests {
+		b, err := Source([]byte(test.input))
+		if err != nil {
+			t.Fatalf("test [%d]: format failed. error: %s", i, err.Error())
+		}
+		if string(b) != test.expected {
+			t.Errorf("test [%d]: expected output is %q. got %q", i, test.expected, string(b))
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// head
+
+
+var a = 1;   // one
+// before f
+
+
+fun f() { // open
+  // body
+  print a;
+
+  // end
+}
+// tail`
+	expected := `// head
+
+var a = 1; // one
+// before f
+
+fun f() { // open
+    // body
+    print a;
+
+    // end
+}
+// tail
+`
+	b, err := Source([]byte(input))
+	if err != nil {
+		t.Fatalf("format failed. error: %s", err.Error())
+	}
+	if string(b) != expected {
+		t.Errorf("expected output is\n%s\ngot\n%s", expected, string(b))
+	}
+}
+
+// TestSourceRoundTrip checks formatting keeps syntax trees and is idempotent.
+func TestSourceRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../example/*.lox")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no example files. error: %v", err)
+	}
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, err := Source(src)
+		if err != nil {
+			t.Fatalf("%s: format failed. error: %s", file, err.Error())
+		}
+		if treeString(t, string(src)) != treeString(t, string(formatted)) {
+			t.Errorf("%s: syntax tree is changed after formatting", file)
+		}
+		again, _ := Source(formatted)
+		if string(again) != string(formatted) {
+			t.Errorf("%s: formatting is not idempotent", file)
+		}
+	}
+}
+
+func treeString(t *testing.T, src string) string {
+	statements, err := parser.ParseStmts(src)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	s := make([]string, len(statements))
+	for i, stmt := range statements {
+		s[i] = stmt.String()
+	}
+	return strings.Join(s, "\n")
+}
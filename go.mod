@@ -1,3 +1,3 @@
 module github.com/ziyoung/lox-go
 
-go 1.12
+go 1.18
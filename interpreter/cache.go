@@ -0,0 +1,68 @@
+package interpreter
+
+import (
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// propertyCache is the inline cache of a get or set expression. It records the
+// result of the last lookup of the property, which holds for every instance of
+// the same shape, since shapes belong to classes whose methods never change.
+type propertyCache struct {
+	shape *valuer.Shape
+	// slot is the slot of the field, or -1 if the instance has no such field.
+	slot int
+	// method is the method found if there is no such field.
+	method *valuer.Function
+	// next is the shape after the field is added by a set expression.
+	next *valuer.Shape
+}
+
+// cacheOf returns the cache stored in the field cache of a node.
+func cacheOf(cache *interface{}) *propertyCache {
+	c, ok := (*cache).(*propertyCache)
+	if !ok {
+		c = &propertyCache{}
+		*cache = c
+	}
+	return c
+}
+
+// getProperty returns the field name of instance, or its method if there is no
+// such field. Both are nil if the property is undefined.
+func getProperty(cache *interface{}, instance *valuer.Instance, name string) (valuer.Valuer, *valuer.Function) {
+	shape := instance.Layout()
+	c := cacheOf(cache)
+	if c.shape != shape {
+		c.shape, c.next = shape, nil
+		c.slot = shape.Slot(name)
+		c.method = nil
+		if c.slot < 0 {
+			c.method = instance.Klass.FindMethod(name)
+		}
+	}
+	if c.slot >= 0 {
+		return instance.Fields[c.slot], nil
+	}
+	return nil, c.method
+}
+
+// setProperty sets the field name of instance to v, adding the field if there's
+// no such field.
+func setProperty(cache *interface{}, instance *valuer.Instance, name string, v valuer.Valuer) {
+	shape := instance.Layout()
+	c := cacheOf(cache)
+	if c.shape != shape {
+		c.shape, c.method = shape, nil
+		c.slot = shape.Slot(name)
+		c.next = nil
+		if c.slot < 0 {
+			c.next = shape.Add(name)
+		}
+	}
+	if c.slot >= 0 {
+		instance.Fields[c.slot] = v
+		return
+	}
+	instance.Shape = c.next
+	instance.Fields = append(instance.Fields, v)
+}
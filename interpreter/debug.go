@@ -0,0 +1,170 @@
+package interpreter
+
+import (
+	"io"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/resolver"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// Frame is an activation of a function, or of top-level code.
+type Frame struct {
+	// Function is the qualified name of the function, such as math.Vector.add, or
+	// "<script>" for top-level code.
+	Function string
+	// File is the absolute name of the file of the code, empty for a program which
+	// isn't read from a file.
+	File string
+	// Pos is the position of the statement being executed.
+	Pos token.Position
+	// Env is the environment of the statement being executed.
+	Env *valuer.Environment
+}
+
+// scriptFrame is the name of the frame of top-level code.
+const scriptFrame = "<script>"
+
+var (
+	// frames is the call stack. The last frame is the innermost one.
+	frames []*Frame
+	// hook is called before each statement is executed.
+	hook func(stmt ast.Stmt)
+	// callHook is called after a frame is pushed and before it is popped.
+	callHook func(function string, returning bool)
+	// branchHook is called when a branch of an if statement or a logical expression is taken.
+	branchHook func(node ast.Node, branch int)
+)
+
+// SetHook sets a function called before each statement is executed.
+// It is used by debuggers and profilers. A nil h removes the hook.
+func SetHook(h func(stmt ast.Stmt)) {
+	hook = h
+}
+
+// SetCallHook sets a function called when a function or top-level code is entered,
+// with returning false, and when it returns, with returning true. It is used by
+// profilers. A nil h removes the hook.
+func SetCallHook(h func(function string, returning bool)) {
+	callHook = h
+}
+
+// SetBranchHook sets a function called when a branch is taken. For an if statement,
+// branch is 0 for the then branch and 1 for the else branch, which may be omitted.
+// For a logical expression, branch is 0 when the right operand is evaluated and 1
+// when it is short-circuited. It is used by coverage tools. A nil h removes the hook.
+func SetBranchHook(h func(node ast.Node, branch int)) {
+	branchHook = h
+}
+
+// SetOutput sets the writer print statements write to. A nil w restores os.Stdout.
+func SetOutput(w io.Writer) {
+	out = w
+}
+
+// Frames returns a copy of the call stack, the innermost frame first.
+func Frames() []Frame {
+	result := make([]Frame, len(frames))
+	for i, f := range frames {
+		result[len(frames)-1-i] = *f
+	}
+	return result
+}
+
+// CurrentFrame returns the innermost frame. It must be called while a program
+// is executed, such as in hooks.
+func CurrentFrame() Frame {
+	return *frames[len(frames)-1]
+}
+
+// Depth returns the number of frames on the call stack.
+func Depth() int {
+	return len(frames)
+}
+
+func pushFrame(name, file string, environment *valuer.Environment) {
+	frames = append(frames, &Frame{Function: name, File: file, Env: environment})
+	if callHook != nil {
+		callHook(name, false)
+	}
+}
+
+// popFrame pops the innermost frame. Frames are not popped by a panic: catch pops
+// them after using the innermost one to locate the error.
+func popFrame() {
+	if callHook != nil {
+		callHook(frames[len(frames)-1].Function, true)
+	}
+	frames = frames[:len(frames)-1]
+}
+
+// unwind pops frames until the call stack has depth frames. It returns the position
+// of the statement being executed in the innermost popped frame, if any.
+func unwind(depth int) token.Position {
+	var pos token.Position
+	if len(frames) > depth {
+		pos = frames[len(frames)-1].Pos
+	}
+	for len(frames) > depth {
+		popFrame()
+	}
+	return pos
+}
+
+// execute evaluates stmt after recording it in the current frame and calling the hook.
+func execute(stmt ast.Stmt) valuer.Valuer {
+	if n := len(frames); n > 0 {
+		frames[n-1].Pos = stmt.Pos()
+		frames[n-1].Env = env
+	}
+	step(stmt)
+	if hook != nil {
+		hook(stmt)
+	}
+	return Eval(stmt)
+}
+
+// branch calls the branch hook if any.
+func branch(node ast.Node, b int) {
+	if branchHook != nil {
+		branchHook(node, b)
+	}
+}
+
+// Execute resolves and executes statements as a script.
+// Runtime errors are returned instead of being reported.
+func Execute(statements []ast.Stmt) (err error) {
+	defer catch(&err, len(frames))
+	statements = beginScript(statements)
+	for _, stmt := range statements {
+		if v := execute(stmt); v != nil && v.Type() == valuer.ReturnType {
+			break
+		}
+	}
+	popFrame()
+	return nil
+}
+
+// EvaluateIn resolves and evaluates expr in environment, which is usually the
+// environment of a frame. Variables are looked up along the environment chain.
+func EvaluateIn(expr ast.Expr, environment *valuer.Environment) (v valuer.Valuer, err error) {
+	defer catch(&err, len(frames))
+	var scopes []map[string]bool
+	moduleGlobals := globalsOf(environment)
+	for e := environment; e != nil && e != moduleGlobals; e = e.Enclosing {
+		scope := make(map[string]bool, len(e.Values))
+		for name := range e.Values {
+			scope[name] = true
+		}
+		scopes = append([]map[string]bool{scope}, scopes...)
+	}
+	resolver.ResolveIn(expr, scopes)
+
+	previous, previousGlobals := env, globals
+	env, globals = environment, moduleGlobals
+	defer func() {
+		env, globals = previous, previousGlobals
+	}()
+	return Eval(expr), nil
+}
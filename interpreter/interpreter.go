@@ -2,8 +2,10 @@ package interpreter
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/ziyoung/lox-go/ast"
 	"github.com/ziyoung/lox-go/errors"
@@ -22,35 +24,44 @@ var (
 var evalEnv string
 
 var (
-	env     *valuer.Environment
+	env *valuer.Environment
+	// globals is the global environment of the module being executed.
 	globals *valuer.Environment
+	// module is the module being executed, nil for the main program.
+	module *valuer.Module
+	// builtins encloses the global environment of every module.
+	builtins *valuer.Environment
 )
 
+// out is where print statements write to. A nil out means os.Stdout.
+var out io.Writer
+
 func init() {
 	initEnv()
+	valuer.SetCaller(CallValue)
 }
 
 func initEnv() {
-	globals = valuer.NewEnv()
+	builtins = valuer.NewEnv()
+	globals = valuer.NewEnclosing(builtins)
 	env = globals
+	module = nil
+	modules = make(map[string]*valuer.Module)
 }
 
-func Interpret(statements []ast.Stmt) {
+// Interpret resolves and executes statements, and reports a runtime error to
+// os.Stderr. The error is also returned.
+func Interpret(statements []ast.Stmt) (err error) {
 	defer func() {
-		if r := recover(); r != nil {
-			if err, ok := r.(errors.RuntimeError); ok {
-				fmt.Fprintln(os.Stderr, err.Error())
-			} else {
-				panic(r)
-			}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
 		}
 	}()
-	for _, stmt := range statements {
-		resolver.Resolve(stmt)
-	}
+	defer catch(&err, len(frames))
+	statements = beginScript(statements)
 	var v valuer.Valuer
 	for _, stmt := range statements {
-		val := Eval(stmt)
+		val := execute(stmt)
 		if val != nil {
 			if val.Type() == valuer.ReturnType {
 				fmt.Fprintf(os.Stderr, "Unexpected return statement %v\n", val)
@@ -59,15 +70,31 @@ func Interpret(statements []ast.Stmt) {
 			}
 		}
 	}
+	popFrame()
 	if v != nil && evalEnv == "repl" {
 		fmt.Printf("%s %s\n", black(v.Type().String()), v)
 	}
+	return nil
+}
+
+// beginScript resolves and optimizes statements to be executed as a script, and
+// pushes the script frame, which the caller pops after executing them.
+func beginScript(statements []ast.Stmt) []ast.Stmt {
+	resolver.Reset()
+	steps = 0
+	for _, stmt := range statements {
+		resolver.Resolve(stmt)
+	}
+	statements = optimize(statements)
+	pushFrame(scriptFrame, importing[0], env)
+	return statements
 }
 
 func Eval(node ast.Node) valuer.Valuer {
 	switch n := node.(type) {
 	default:
-		panic(fmt.Sprintf("unknown ast type %#v.", n))
+		errors.Error(token.EOF, fmt.Sprintf("Cannot evaluate %T.", n))
+		return nil
 	case *ast.Literal:
 		return evalLiteral(n)
 	case *ast.BinaryExpr:
@@ -76,6 +103,8 @@ func Eval(node ast.Node) valuer.Valuer {
 		return evalUnaryExpr(n)
 	case *ast.GroupingExpr:
 		return Eval(n.Expression)
+	case *ast.InterpolationExpr:
+		return evalInterpolationExpr(n)
 	case *ast.VariableExpr:
 		return evalVariableExpr(n)
 	case *ast.AssignExpr:
@@ -107,11 +136,16 @@ func Eval(node ast.Node) valuer.Valuer {
 		return evalIfStmt(n)
 	case *ast.WhileStmt:
 		return evalWhileStmt(n)
+	case *ast.ForStmt:
+		return evalForStmt(n)
 	case *ast.ReturnStmt:
 		return evalReturnStmt(n)
 	case *ast.ClassStmt:
 		evalClassStmt(n)
 		return nil
+	case *ast.ImportStmt:
+		evalImportStmt(n)
+		return nil
 	}
 }
 
@@ -125,15 +159,17 @@ func evalLiteral(lit *ast.Literal) valuer.Valuer {
 		return &valuer.String{Value: lit.Value}
 	case token.Number:
 		v, err := strconv.ParseFloat(lit.Value, 64)
-		if err != nil {
-			panic(err)
+		// A number out of range is infinity or zero as in other implementations of Lox.
+		if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+			errors.ErrorAt(lit.ValuePos, token.Number, fmt.Sprintf("Invalid number %s.", lit.Value))
 		}
 		return &valuer.Number{Value: v}
 	case token.Nil:
 		return Nil
 	}
 
-	panic("unexpected literal.")
+	errors.ErrorAt(lit.ValuePos, lit.Token, fmt.Sprintf("Unexpected literal %s.", lit.Token))
+	return nil
 }
 
 func evalBinaryExpr(expr *ast.BinaryExpr) valuer.Valuer {
@@ -142,10 +178,10 @@ func evalBinaryExpr(expr *ast.BinaryExpr) valuer.Valuer {
 
 	switch op := expr.Operator; op {
 	case token.EqualEqual:
-		t := isEqual(left, right)
+		t := valuer.Equal(left, right)
 		return toBooleanValuer(t)
 	case token.BangEqual:
-		t := !isEqual(left, right)
+		t := !valuer.Equal(left, right)
 		return toBooleanValuer(t)
 	case token.Greater:
 		a, b := checkNumberOperands(op, left, right)
@@ -182,21 +218,46 @@ func evalBinaryExpr(expr *ast.BinaryExpr) valuer.Valuer {
 		return &valuer.Number{Value: v}
 	}
 
-	panic("unexpected binary expression.")
+	errors.Error(expr.Operator, fmt.Sprintf("Unexpected binary operator %s.", expr.Operator))
+	return nil
 }
 
 func evalUnaryExpr(expr *ast.UnaryExpr) valuer.Valuer {
 	right := Eval(expr.Right)
 	switch op := expr.Operator; op {
 	case token.Bang:
-		t := !isTruthy(right)
+		t := !valuer.IsTruthy(right)
 		return toBooleanValuer(t)
 	case token.Minus:
 		v := checkNumberOperand(op, right)
 		return &valuer.Number{Value: -v}
 	}
 
-	panic("unexpected unary expression.")
+	errors.Error(expr.Operator, fmt.Sprintf("Unexpected unary operator %s.", expr.Operator))
+	return nil
+}
+
+func evalInterpolationExpr(expr *ast.InterpolationExpr) valuer.Valuer {
+	var b strings.Builder
+	for i, s := range expr.Strings {
+		b.WriteString(s)
+		if i < len(expr.Exprs) {
+			b.WriteString(toString(Eval(expr.Exprs[i])))
+		}
+	}
+	return &valuer.String{Value: b.String()}
+}
+
+// toString converts v to a string for interpolation. An instance is converted by
+// its toString method if it has one.
+func toString(v valuer.Valuer) string {
+	if instance, ok := v.(*valuer.Instance); ok {
+		if method := instance.Klass.FindMethod("toString"); method != nil {
+			checkArity(method, nil)
+			return callFunction(method, instance.Bound(method.Closure), nil).String()
+		}
+	}
+	return v.String()
 }
 
 func evalVariableExpr(expr *ast.VariableExpr) valuer.Valuer {
@@ -234,94 +295,217 @@ func evalLogicalExpr(expr *ast.LogicalExpr) valuer.Valuer {
 	left := Eval(expr.Left)
 	switch expr.Operator {
 	default:
-		panic(fmt.Sprintf("unknown operator %s", expr.Operator))
+		errors.Error(expr.Operator, fmt.Sprintf("Unexpected logical operator %s.", expr.Operator))
 	case token.Or:
-		if isTruthy(left) {
+		if valuer.IsTruthy(left) {
+			branch(expr, 1)
 			return left
 		}
 	case token.And:
-		if !isTruthy(left) {
+		if !valuer.IsTruthy(left) {
+			branch(expr, 1)
 			return left
 		}
 	}
+	branch(expr, 0)
 	return Eval(expr.Right)
 }
 
 func evalCallExpr(expr *ast.CallExpr) valuer.Valuer {
-	callee := Eval(expr.Callee)
-	callableValue, ok := callee.(valuer.Callable)
+	callee, this := evalCallee(expr.Callee)
+	args := make([]valuer.Valuer, len(expr.Arguments))
+	for i, arg := range expr.Arguments {
+		args[i] = Eval(arg)
+	}
+	if this != nil {
+		method := callee.(*valuer.Function)
+		checkArity(method, args)
+		return callFunction(method, this, args)
+	}
+	return call(callee, args)
+}
+
+// evalCallee evaluates the callee of a call. For a method called as obj.method(),
+// it returns the method with the environment binding this, instead of allocating
+// the bound method.
+func evalCallee(expr ast.Expr) (callee valuer.Valuer, this *valuer.Environment) {
+	get, ok := expr.(*ast.GetExpr)
 	if !ok {
-		errors.Error(token.LeftParen, "Can only call functions and classes.")
-		return nil
+		return Eval(expr), nil
 	}
-	if l, l1 := callableValue.Arity(), len(expr.Arguments); l != l1 {
-		errors.Error(token.LeftParen, fmt.Sprintf("Expected %d arguments but got %d", l, l1))
-		return nil
+	object := Eval(get.Object)
+	instance, ok := object.(*valuer.Instance)
+	if !ok {
+		return getExpr(get, object), nil
 	}
+	v, method := getProperty(&get.Cache, instance, get.Name)
+	if method != nil {
+		return method, instance.Bound(method.Closure)
+	}
+	if v == nil {
+		errors.Error(token.Identifier, fmt.Sprintf("Undefined property %s.", get.Name))
+	}
+	return v, nil
+}
+
+func call(callee valuer.Valuer, args []valuer.Valuer) valuer.Valuer {
+	checkArity(callee, args)
 
 	switch n := callee.(type) {
 	default:
-		panic("invaid type")
+		errors.Error(token.LeftParen, "Can only call functions and classes.")
+		return nil
 	case *valuer.Function:
-		return callFunction(n, expr.Arguments)
+		return callFunction(n, n.Closure, args)
 	case *valuer.ClassValue:
-		return constructInstance(n, expr.Arguments)
+		return constructInstance(n, args)
+	case *valuer.NativeFunction:
+		return callNative(n, args)
 	}
 }
 
-func constructInstance(c *valuer.ClassValue, arguments []ast.Expr) *valuer.Instance {
-	instance := &valuer.Instance{Klass: c}
+// checkArity reports an error if callee can't be called with args.
+func checkArity(callee valuer.Valuer, args []valuer.Valuer) {
+	callableValue, ok := callee.(valuer.Callable)
+	if !ok {
+		errors.Error(token.LeftParen, "Can only call functions and classes.")
+		return
+	}
+	if l, l1 := callableValue.Arity(), len(args); l >= 0 && l != l1 {
+		errors.Error(token.LeftParen, fmt.Sprintf("Expected %d arguments but got %d", l, l1))
+	}
+}
+
+func callNative(function *valuer.NativeFunction, args []valuer.Valuer) valuer.Valuer {
+	v, err := function.Fn(args)
+	if err != nil {
+		errors.Error(token.LeftParen, err.Error())
+		return nil
+	}
+	if v == nil {
+		return Nil
+	}
+	return v
+}
+
+func constructInstance(c *valuer.ClassValue, args []valuer.Valuer) *valuer.Instance {
+	instance := valuer.NewInstance(c)
 	initializer := c.FindMethod("init")
 	if initializer != nil {
-		callFunction(initializer.Bind(instance), arguments)
+		callFunction(initializer, instance.Bound(initializer.Closure), args)
 	}
 	return instance
 }
 
-func callFunction(function *valuer.Function, arguments []ast.Expr) valuer.Valuer {
-	environment := function.Closure
-	environment = valuer.NewEnclosing(function.Closure)
+// callFunction calls function with closure, which is function.Closure unless the
+// function is a method called without being bound. Tail calls returned by the
+// function are made in a loop after its frame is popped, so they don't grow the Go stack.
+func callFunction(function *valuer.Function, closure *valuer.Environment, args []valuer.Valuer) valuer.Valuer {
+	for {
+		v := runFunction(function, closure, args)
+		tc, ok := v.(*tailCall)
+		if !ok {
+			return v
+		}
+		function, closure, args = tc.function, tc.closure, tc.args
+	}
+}
+
+// runFunction executes the body of function. It returns a *tailCall if the function
+// returns with a tail call.
+func runFunction(function *valuer.Function, closure *valuer.Environment, args []valuer.Valuer) valuer.Valuer {
+	environment := valuer.NewEnclosing(closure)
 	for i, param := range function.Params {
-		environment.Define(param.Name, Eval(arguments[i]))
+		environment.Define(param.Name, args[i])
 	}
+	checkDepth()
+	previous, previousModule := globals, module
+	globals, module = globalsOf(closure), function.Module
+	defer func() {
+		globals, module = previous, previousModule
+	}()
+	pushFrame(function.QualifiedName(), fileOf(function), environment)
 	v := executeBlock(function.Body, environment)
+	popFrame()
 	if function.IsInitializer {
-		// lookup this in function.Closure
-		if v, ok := function.Closure.GetAt(0, "this"); ok {
+		// lookup this in closure
+		if v, ok := closure.GetAt(0, "this"); ok {
 			return v
 		}
 		errors.Error(token.Fun, "Cann't get this in currrent enviroment.")
 		return nil
 	}
-	if returnValue, ok := v.(*valuer.ReturnValue); ok {
-		return returnValue.Value
+	switch rv := v.(type) {
+	case *valuer.ReturnValue:
+		return rv.Value
+	case *tailCall:
+		return rv
 	}
 	return v
 }
 
+// tailCall is the result of a return statement whose value is a call of a Lox
+// function. It is returned like a return value, and the call is made by callFunction.
+type tailCall struct {
+	function *valuer.Function
+	closure  *valuer.Environment
+	args     []valuer.Valuer
+}
+
+func (*tailCall) Type() valuer.Type { return valuer.ReturnType }
+
+func (tc *tailCall) String() string { return "<tail call " + tc.function.Name + ">" }
+
 func evalGetExpr(expr *ast.GetExpr) valuer.Valuer {
-	object := Eval(expr.Object)
+	return getExpr(expr, Eval(expr.Object))
+}
+
+// getExpr returns the property of object accessed by expr.
+func getExpr(expr *ast.GetExpr, object valuer.Valuer) valuer.Valuer {
+	if o, ok := object.(*valuer.GoObject); ok {
+		v, err := o.Get(expr.Name)
+		if err != nil {
+			errors.Error(token.Identifier, err.Error())
+		}
+		return v
+	}
+	if m, ok := object.(*valuer.Module); ok {
+		if v, ok := m.Get(expr.Name); ok {
+			return v
+		}
+		errors.Error(token.Identifier, fmt.Sprintf("Module %s has no member %s.", m.Name, expr.Name))
+	}
 	instance, ok := object.(*valuer.Instance)
 	if !ok {
 		errors.Error(token.Identifier, "Only instances have properties.")
 		return nil
 	}
-	if v, ok := instance.Get(expr.Name); ok {
-		return v
+	v, method := getProperty(&expr.Cache, instance, expr.Name)
+	if method != nil {
+		return method.Bind(instance)
 	}
-	errors.Error(token.Identifier, fmt.Sprintf("Undefined propterty %s.", expr.Name))
-	return nil
+	if v == nil {
+		errors.Error(token.Identifier, fmt.Sprintf("Undefined property %s.", expr.Name))
+	}
+	return v
 }
 
 func evalSetExpr(expr *ast.SetExpr) valuer.Valuer {
 	object := Eval(expr.Object)
+	if o, ok := object.(*valuer.GoObject); ok {
+		v := Eval(expr.Value)
+		if err := o.Set(expr.Name, v); err != nil {
+			errors.Error(token.Identifier, err.Error())
+		}
+		return v
+	}
 	instance, ok := object.(*valuer.Instance)
 	if !ok {
 		errors.Error(token.Identifier, "Only instances have properties.")
 		return nil
 	}
 	v := Eval(expr.Value)
-	instance.Set(expr.Name, v)
+	setProperty(&expr.Cache, instance, expr.Name, v)
 	return v
 }
 
@@ -350,7 +534,11 @@ func evalVarStmt(stmt *ast.VarStmt) {
 
 func evalPrintStmt(stmt *ast.PrintStmt) {
 	v := Eval(stmt.Expression)
-	fmt.Println(v)
+	if out == nil {
+		fmt.Println(v)
+		return
+	}
+	fmt.Fprintln(out, v)
 }
 
 func evalBlockStmt(block *ast.BlockStmt) valuer.Valuer {
@@ -364,7 +552,7 @@ func executeBlock(statements []ast.Stmt, environment *valuer.Environment) valuer
 		env = previous
 	}()
 	for _, stmt := range statements {
-		result := Eval(stmt)
+		result := execute(stmt)
 		if result != nil {
 			if rt := result.Type(); rt == valuer.ReturnType {
 				return result
@@ -376,17 +564,20 @@ func executeBlock(statements []ast.Stmt, environment *valuer.Environment) valuer
 
 func evalIfStmt(stmt *ast.IfStmt) valuer.Valuer {
 	condition := Eval(stmt.Condition)
-	if isTruthy(condition) {
-		return Eval(stmt.ThenBranch)
-	} else if stmt.ElseBranch != nil {
-		return Eval(stmt.ElseBranch)
+	if valuer.IsTruthy(condition) {
+		branch(stmt, 0)
+		return execute(stmt.ThenBranch)
+	}
+	branch(stmt, 1)
+	if stmt.ElseBranch != nil {
+		return execute(stmt.ElseBranch)
 	}
 	return Nil
 }
 
 func evalWhileStmt(stmt *ast.WhileStmt) valuer.Valuer {
-	for isTruthy(Eval(stmt.Condition)) {
-		result := Eval(stmt.Body)
+	for valuer.IsTruthy(Eval(stmt.Condition)) {
+		result := execute(stmt.Body)
 		if result != nil {
 			if rt := result.Type(); rt == valuer.ReturnType {
 				return result
@@ -396,9 +587,33 @@ func evalWhileStmt(stmt *ast.WhileStmt) valuer.Valuer {
 	return Nil
 }
 
+func evalForStmt(stmt *ast.ForStmt) valuer.Valuer {
+	previous := env
+	env = valuer.NewEnclosing(env)
+	defer func() {
+		env = previous
+	}()
+	if stmt.Initializer != nil {
+		Eval(stmt.Initializer)
+	}
+	for stmt.Condition == nil || valuer.IsTruthy(Eval(stmt.Condition)) {
+		result := execute(stmt.Body)
+		if result != nil {
+			if rt := result.Type(); rt == valuer.ReturnType {
+				return result
+			}
+		}
+		if stmt.Increment != nil {
+			Eval(stmt.Increment)
+		}
+	}
+	return Nil
+}
+
 func evalFunctionStmt(stmt *ast.FunctionStmt) {
 	fn := &valuer.Function{
 		Name:    stmt.Name,
+		Module:  module,
 		Params:  stmt.Params,
 		Body:    stmt.Body,
 		Closure: env,
@@ -407,6 +622,9 @@ func evalFunctionStmt(stmt *ast.FunctionStmt) {
 }
 
 func evalReturnStmt(stmt *ast.ReturnStmt) valuer.Valuer {
+	if tc := evalTailCall(stmt.Value); tc != nil {
+		return tc
+	}
 	var v valuer.Valuer = Nil
 	if stmt.Value != nil {
 		v = Eval(stmt.Value)
@@ -416,11 +634,44 @@ func evalReturnStmt(stmt *ast.ReturnStmt) valuer.Valuer {
 	}
 }
 
+// evalTailCall evaluates the callee and arguments of expr if it is a call, possibly
+// parenthesized. It returns a tail call if the callee is a Lox function. Other
+// callees are called right away.
+func evalTailCall(expr ast.Expr) valuer.Valuer {
+	for {
+		grouping, ok := expr.(*ast.GroupingExpr)
+		if !ok {
+			break
+		}
+		expr = grouping.Expression
+	}
+	callExpr, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	callee, this := evalCallee(callExpr.Callee)
+	args := make([]valuer.Valuer, len(callExpr.Arguments))
+	for i, arg := range callExpr.Arguments {
+		args[i] = Eval(arg)
+	}
+	fn, ok := callee.(*valuer.Function)
+	if !ok {
+		return &valuer.ReturnValue{Value: call(callee, args)}
+	}
+	checkArity(fn, args)
+	if this == nil {
+		this = fn.Closure
+	}
+	return &tailCall{function: fn, closure: this, args: args}
+}
+
 func evalClassStmt(stmt *ast.ClassStmt) {
 	methods := make(map[string]*valuer.Function, len(stmt.Methods))
 	for _, method := range stmt.Methods {
 		fn := &valuer.Function{
 			Name:          method.Name,
+			Class:         stmt.Name,
+			Module:        module,
 			Params:        method.Params,
 			Body:          method.Body,
 			Closure:       env,
@@ -439,6 +690,7 @@ func checkNumberOperand(operator token.Token, right valuer.Valuer) float64 {
 	a, ok := right.(*valuer.Number)
 	if !ok {
 		errors.Error(operator, "Operand must be a number.")
+		return 0
 	}
 	return a.Value
 }
@@ -448,6 +700,7 @@ func checkNumberOperands(operator token.Token, left, right valuer.Valuer) (float
 	b, ok1 := right.(*valuer.Number)
 	if !(ok && ok1) {
 		errors.Error(operator, "Operands must be numbers.")
+		return 0, 0
 	}
 	return a.Value, b.Value
 }
@@ -479,47 +732,6 @@ func doPlusOperation(left, right valuer.Valuer) valuer.Valuer {
 	return nil
 }
 
-func isEqual(a, b valuer.Valuer) bool {
-	_, ok := a.(*valuer.Boolean)
-	_, ok1 := b.(*valuer.Boolean)
-	if ok || ok1 {
-		return isTruthy(a) == isTruthy(b)
-	}
-
-	switch a1 := a.(type) {
-	case *valuer.Number:
-		if b1, ok := b.(*valuer.Number); ok {
-			return a1.Value == b1.Value
-		}
-	case *valuer.Nil:
-		if _, ok := b.(*valuer.Nil); ok {
-			return true
-		}
-	case *valuer.String:
-		if b1, ok := b.(*valuer.String); ok {
-			return a1.Value == b1.Value
-		}
-	}
-	return false
-}
-
-func isTruthy(value valuer.Valuer) bool {
-	if value == nil {
-		return false
-	}
-	switch v := value.(type) {
-	case *valuer.Boolean:
-		return v.Value
-	case *valuer.Number:
-		return v.Value != float64(0)
-	case *valuer.Nil:
-		return false
-	case *valuer.String:
-		return v.Value != ""
-	}
-	return false
-}
-
 func toBooleanValuer(t bool) *valuer.Boolean {
 	if t {
 		return True
@@ -531,6 +743,64 @@ func black(s string) string {
 	return "\033[1;30m" + s + "\033[0m"
 }
 
+// Define binds name to v in the environment enclosing the global environment of
+// every module. It is used by embedders to expose Go values to Lox programs.
+func Define(name string, v valuer.Valuer) {
+	builtins.Define(name, v)
+}
+
+// Call calls the global function or class named name with args.
+// Runtime errors are returned instead of being reported.
+func Call(name string, args ...valuer.Valuer) (valuer.Valuer, error) {
+	callee, ok := globals.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined function %s", name)
+	}
+	return CallValue(callee, args...)
+}
+
+// CallValue calls a function or class value with args.
+func CallValue(callee valuer.Valuer, args ...valuer.Valuer) (v valuer.Valuer, err error) {
+	defer catch(&err, len(frames))
+	return call(callee, args), nil
+}
+
+// Evaluate resolves and evaluates expr in the global environment.
+// Runtime errors are returned instead of being reported.
+func Evaluate(expr ast.Expr) (v valuer.Valuer, err error) {
+	defer catch(&err, len(frames))
+	resolver.Reset()
+	resolver.Resolve(expr)
+	return Eval(expr), nil
+}
+
+// Globals returns the global environment.
+func Globals() *valuer.Environment {
+	return globals
+}
+
+// Reset discards all global bindings and loaded modules.
+func Reset() {
+	initEnv()
+}
+
+// catch recovers a runtime error into err, and pops frames left by the panic until
+// the call stack has depth frames. A runtime error without position gets the
+// position of the statement being executed in the innermost frame.
+func catch(err *error, depth int) {
+	if r := recover(); r != nil {
+		pos := unwind(depth)
+		runErr, ok := r.(errors.RuntimeError)
+		if !ok {
+			panic(r)
+		}
+		if !runErr.Pos().IsValid() && pos.IsValid() {
+			runErr = runErr.At(pos)
+		}
+		*err = &runErr
+	}
+}
+
 // SetEvalEnv specify eval env of Interpreter.
 func SetEvalEnv(envConfig string) {
 	evalEnv = envConfig
@@ -1,13 +1,19 @@
 package interpreter
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/bench/workload"
 	"github.com/ziyoung/lox-go/errors"
 	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/token"
 	"github.com/ziyoung/lox-go/valuer"
 )
 
@@ -111,6 +117,41 @@ func TestEvalString(t *testing.T) {
 	}
 }
 
+func TestEvalInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{`var x = 1; print "x = ${x}, x + 1 = ${x + 1}";`, "x = 1, x + 1 = 2\n", ""},
+		{`print "${nil} ${true} ${"s"} ${1 == 2}";`, "nil true s false\n", ""},
+		{`fun f() {} class A {} print "${f} ${A} ${A()}";`, "<fn f> class A A instance\n", ""},
+		{`class A { toString() { return "a" + 1; } } print "<${A()}>";`, "<a1>\n", ""},
+		{`class A { toString() { return this; } } print "${A()}";`, "A instance\n", ""},
+		{`var s = "a"; print "${"${s}${s}"}" + s;`, "aaa\n", ""},
+		{`print "\${x}";`, "${x}\n", ""},
+		{`class A { toString(x) {} } print "${A()}";`, "", "1:28 Expected 1 arguments but got 0"},
+		{`print "${-nil}";`, "", "1:1 Operand must be a number."},
+	}
+	for i, tt := range tests {
+		stmts, err := parser.ParseStmts(tt.input)
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err.Error())
+		}
+		initEnv()
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		err = Execute(stmts)
+		SetOutput(nil)
+		if buf.String() != tt.expected {
+			t.Errorf("test [%d]: output wrong. expected=%q, got=%q", i, tt.expected, buf.String())
+		}
+		if msg := fmt.Sprint(err); (err != nil || tt.err != "") && msg != tt.err {
+			t.Errorf("test [%d]: expected error %q. got %v", i, tt.err, err)
+		}
+	}
+}
+
 func TestEvalLogicExpr(t *testing.T) {
 	input := `print 1 or 2;
 print nil or "xx";
@@ -233,6 +274,20 @@ func TestFunctionClosure(t *testing.T) {
 	testEvalPrintStmt(t, input, expected)
 }
 
+func TestShadowing(t *testing.T) {
+	input := `
+	{
+		var a = 1;
+		{
+			var a = 2;
+			print a;
+		}
+		print a;
+	}`
+	expected := []string{"2", "1"}
+	testEvalPrintStmt(t, input, expected)
+}
+
 func TestEvalClass(t *testing.T) {
 	input := `class A {
 		fn() {
@@ -292,6 +347,50 @@ func TestEvalThisAndInit(t *testing.T) {
 	testEvalPrintStmt(t, input, expected)
 }
 
+func TestInlineCache(t *testing.T) {
+	input := `class A {
+		init(x) { this.x = x; }
+		get() { return "A" + this.x; }
+	}
+	class B {
+		get() { return "B"; }
+	}
+	fun show(o) { print o.get(); }
+	fun peek(o) { print o.get; }
+	fun getX(o) { return o.x; }
+	fun setX(o, x) { o.x = x; }
+
+	var a = A(1);
+	var b = B();
+	for (var i = 0; i < 2; i = i + 1) {
+		show(a);
+		show(b);
+	}
+	peek(b);
+	b.get = getX;
+	peek(b);
+	b.x = "x";
+	setX(b, "y");
+	setX(a, 2);
+	print getX(b) + getX(a);
+	var c = B();
+	c.y = 0;
+	setX(c, "z");
+	print getX(c);
+	var get = a.get;
+	setX(a, 3);
+	print get();`
+	expected := []string{
+		"A1", "B", "A1", "B",
+		"<fn get>",
+		"<fn getX>", // the field shadows the method
+		"y2",
+		"z",
+		"A3",
+	}
+	testEvalPrintStmt(t, input, expected)
+}
+
 func TestResolveError(t *testing.T) {
 	tests := []struct {
 		input string
@@ -433,3 +532,442 @@ func splitByLine(s string) []string {
 	s = strings.TrimSpace(s)
 	return strings.Split(s, "\n")
 }
+
+type account struct {
+	Owner   string
+	Balance float64
+}
+
+func (a *account) Deposit(amount float64) float64 {
+	a.Balance += amount
+	return a.Balance
+}
+
+func TestEvalGoObject(t *testing.T) {
+	input := `print acc;
+	print acc.Owner;
+	acc.Balance = 10;
+	print acc.Deposit(5);
+	print double(acc.Balance);`
+	expected := []string{"account instance", "bob", "15", "30"}
+	acc := &account{Owner: "bob"}
+	stmts, err := parser.ParseStmts(input)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	initEnv()
+	o, _ := valuer.Bind(acc)
+	Define("acc", o)
+	double, _ := valuer.FromGo(func(n float64) float64 { return n * 2 })
+	Define("double", double)
+	out := splitByLine(captureStdout(func() {
+		Interpret(stmts)
+	}))
+	if strings.Join(out, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected output is %v. got %v", expected, out)
+	}
+	if acc.Balance != 15 {
+		t.Errorf("expected balance is 15. got %v", acc.Balance)
+	}
+}
+
+func TestCall(t *testing.T) {
+	input := `fun add(a, b) {
+		return a + b;
+	}
+	class Counter {
+		init(n) {
+			this.n = n;
+		}
+		inc() {
+			this.n = this.n + 1;
+			return this.n;
+		}
+	}
+	fun fail() {
+		return 1 + nil;
+	}`
+	stmts, err := parser.ParseStmts(input)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	initEnv()
+	Interpret(stmts)
+
+	v, err := Call("add", &valuer.Number{Value: 1}, &valuer.Number{Value: 2})
+	if err != nil {
+		t.Fatalf("call add failed. error: %s", err.Error())
+	}
+	testNumberValuer(t, v, 3)
+
+	v, err = Call("Counter", &valuer.Number{Value: 10})
+	if err != nil {
+		t.Fatalf("call Counter failed. error: %s", err.Error())
+	}
+	inc, ok := v.(*valuer.Instance).Get("inc")
+	if !ok {
+		t.Fatalf("method inc is not found")
+	}
+	v, err = inc.(*valuer.Function).Call()
+	if err != nil {
+		t.Fatalf("call inc failed. error: %s", err.Error())
+	}
+	testNumberValuer(t, v, 11)
+
+	if _, err := Call("add", &valuer.Number{Value: 1}); err == nil {
+		t.Errorf("call add with 1 argument should fail")
+	}
+	if _, err := Call("fail"); err == nil {
+		t.Errorf("call fail should return runtime error")
+	}
+	if _, err := Call("missing"); err == nil {
+		t.Errorf("call missing should fail")
+	}
+}
+
+func TestHook(t *testing.T) {
+	input := `fun f(n) {
+		var m = n * 2;
+		return m;
+	}
+	var a = f(1);
+	print a;`
+	stmts, err := parser.ParseStmts(input)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	initEnv()
+	var trace []string
+	var local valuer.Valuer
+	SetHook(func(stmt ast.Stmt) {
+		frames := Frames()
+		trace = append(trace, fmt.Sprintf("%s:%d", frames[0].Function, stmt.Pos().Line))
+		if stmt.Pos().Line == 3 {
+			expr, _ := parser.ParseExpr("m + n")
+			local, _ = EvaluateIn(expr, frames[0].Env)
+		}
+	})
+	defer SetHook(nil)
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(nil)
+	if err := Execute(stmts); err != nil {
+		t.Fatalf("execute failed. error: %s", err.Error())
+	}
+
+	expected := "[<script>:1 <script>:5 f:2 f:3 <script>:6]"
+	if fmt.Sprint(trace) != expected {
+		t.Errorf("expected trace is %s. got %v", expected, trace)
+	}
+	testNumberValuer(t, local, 3)
+	if buf.String() != "2\n" {
+		t.Errorf("expected output is %q. got %q", "2\n", buf.String())
+	}
+	if Depth() != 0 {
+		t.Errorf("expected empty call stack after execution. got %d frames", Depth())
+	}
+}
+
+func TestRuntimeErrorPos(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print -\"a\";", "1:1 Operand must be a number."},
+		{"fun f(a) {\n  print a;\n  return a + 1;\n}\nf(nil);", "3:3 Operands must be numbers or strings."},
+		{"var a = 1;\na();", "2:1 Can only call functions and classes."},
+	}
+	for i, tt := range tests {
+		stmts, err := parser.ParseStmts(tt.input)
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err.Error())
+		}
+		initEnv()
+		SetOutput(&bytes.Buffer{})
+		err = Execute(stmts)
+		SetOutput(nil)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("test [%d]: expected error %q. got %v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestOtherPanic(t *testing.T) {
+	stmts, err := parser.ParseStmts("fun f() {\n  boom();\n}\nf();")
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	initEnv()
+	boom := fmt.Errorf("boom")
+	Define("boom", &valuer.NativeFunction{
+		Name: "boom",
+		Fn: func(args []valuer.Valuer) (valuer.Valuer, error) {
+			panic(boom)
+		},
+	})
+	defer func() {
+		if r := recover(); r != boom {
+			t.Errorf("expected panic %v. got %v", boom, r)
+		}
+		if Depth() != 0 {
+			t.Errorf("expected empty call stack after panic. got %d frames", Depth())
+		}
+	}()
+	Execute(stmts)
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   Limits
+		expected string
+	}{
+		{"var i = 0;\nwhile (true) {\n  i = i + 1;\n}", Limits{Steps: 100}, "2:14 Execution step limit exceeded."},
+		{"fun f(n) {\n  return 1 + f(n + 1);\n}\nf(0);", Limits{Depth: 100}, "2:3 Stack overflow."},
+		{"fun f(n) {\n  return 1 + f(n + 1);\n}\nf(0);", Limits{Depth: DefaultDepth}, "2:3 Stack overflow."},
+		// tail calls don't grow the stack.
+		{"fun f(n) {\n  return f(n + 1);\n}\nf(0);", Limits{Steps: 100000, Depth: 100}, "2:3 Execution step limit exceeded."},
+		{"print 1e999;", Limits{}, ""},
+	}
+	defer SetLimits(Limits{Depth: DefaultDepth})
+	for i, tt := range tests {
+		stmts, err := parser.ParseStmts(tt.input)
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err.Error())
+		}
+		initEnv()
+		SetLimits(tt.limits)
+		SetOutput(&bytes.Buffer{})
+		err = Execute(stmts)
+		SetOutput(nil)
+		if tt.expected == "" && err != nil {
+			t.Errorf("test [%d]: unexpected error %v", i, err)
+		} else if tt.expected != "" && (err == nil || err.Error() != tt.expected) {
+			t.Errorf("test [%d]: expected error %q. got %v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestTailCall(t *testing.T) {
+	// tail calls, possibly parenthesized and mutually recursive, don't grow the stack.
+	SetLimits(Limits{Depth: 100})
+	defer SetLimits(Limits{Depth: DefaultDepth})
+	input := `fun even(n) { if (n == 0) return true; return odd(n - 1); }
+fun odd(n) { if (n == 0) return false; return (even(n - 1)); }
+fun count(n) { if (n > 0) return count(n - 1); return "done"; }
+class Counter {
+  init(n) { this.n = n; }
+  down() { if (this.n == 0) return this.n; this.n = this.n - 1; return this.down(); }
+}
+print even(100000);
+print odd(100001);
+print count(100000);
+print Counter(10000).down();`
+	testEvalPrintStmt(t, input, []string{"true", "true", "done", "0"})
+}
+
+func TestInvalidNode(t *testing.T) {
+	tests := []struct {
+		expr     ast.Expr
+		expected string
+	}{
+		{&ast.SuperExpr{Method: "m"}, "Cannot use 'super' outside of a class."},
+		{&ast.Literal{Token: token.EOF}, "Unexpected literal EOF."},
+		{&ast.BinaryExpr{Left: &ast.Literal{Token: token.Nil}, Operator: token.Comma, Right: &ast.Literal{Token: token.Nil}}, "Unexpected binary operator ,."},
+		{&ast.UnaryExpr{Operator: token.Plus, Right: &ast.Literal{Token: token.Nil}}, "Unexpected unary operator +."},
+	}
+	for i, tt := range tests {
+		_, err := Evaluate(tt.expr)
+		if runErr, ok := err.(*errors.RuntimeError); !ok || runErr.Message() != tt.expected {
+			t.Errorf("test [%d]: expected error %q. got %v", i, tt.expected, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{`import "math.lox" as m;
+print m.square(3);
+print m.pi;
+print m;`, "load math\n9\n3.14\n<module math.lox>\n", ""},
+		{`from "math" import square, calls;
+import "math" as m;
+square(2);
+m.square(2);
+print calls();`, "load math\n2\n", ""},
+		{`from "strings" import twice;
+print twice("ab");`, "abab\n", ""},
+		{`import "math" as m;
+print m._calls;`, "load math\n", "2:1 Module math has no member _calls."},
+		{`from "math" import square, _calls;`, "load math\n", "1:28 Module math has no member _calls."},
+		{`import "missing" as m;`, "", "1:8 Cannot find module missing."},
+		{`import "error" as e;`, "", "1:1 testdata/modules/error.lox:2:1: Operand must be a number."},
+		{`import "cycle_a" as a;`, "", "1:1 testdata/modules/cycle_a.lox:1:1: testdata/modules/cycle_b.lox:1:1: " +
+			"Import cycle: testdata/modules/cycle_a.lox -> testdata/modules/cycle_b.lox -> testdata/modules/cycle_a.lox."},
+		{`fun f() {
+  import "math" as m;
+}`, "", "2:3 Can only import at top level."},
+	}
+	SetFile("testdata/modules/main.lox")
+	SetPath([]string{"testdata/modules/lib"})
+	defer func() {
+		SetFile("")
+		SetPath(nil)
+	}()
+	for i, tt := range tests {
+		stmts, err := parser.ParseStmts(tt.input)
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err.Error())
+		}
+		initEnv()
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		err = Execute(stmts)
+		SetOutput(nil)
+		if buf.String() != tt.expected {
+			t.Errorf("test [%d]: output wrong. expected=%q, got=%q", i, tt.expected, buf.String())
+		}
+		if msg := fmt.Sprint(err); (err != nil || tt.err != "") && msg != tt.err {
+			t.Errorf("test [%d]: expected error %q. got %v", i, tt.err, err)
+		}
+	}
+}
+
+func FuzzInterpret(f *testing.F) {
+	files, err := filepath.Glob("../example/*.lox")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(b))
+	}
+	SetOutput(ioutil.Discard)
+	SetLimits(Limits{Steps: 10000, Depth: 100})
+	defer func() {
+		SetOutput(nil)
+		SetLimits(Limits{Depth: DefaultDepth})
+	}()
+	f.Fuzz(func(t *testing.T, input string) {
+		statements, err := parser.ParseStmts(input)
+		if err != nil {
+			return
+		}
+		Reset()
+		Execute(statements)
+	})
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []string{
+		"var day = 60 * 60 * 24; print day; print day / 3600 + \"h\";",
+		"print 1 + 2 * 3 == 7 and \"yes\" or \"no\";",
+		"if (1 > 2) print 1; else if (nil) print 2; else { var a = 3; print a; }",
+		"fun f() { return 1; print 2; } print f(); while (false) print 3;",
+		"var i = 0; while (i < 2 * 2) { if (true) i = i + 1; } print i;",
+		"print 2 or f();",
+		"print 1 / 0;",
+		"print 1; print -\"a\";",
+		"var a = 1; print a + nil;",
+		"print \"a\" + true;",
+		"var a = 2; print \"${1 / 3} ${a} ${1e21} ${nil and a} ${-a}\";",
+	}
+	defer SetOptimize(true)
+	for i, input := range tests {
+		var results [2]string
+		for j, enabled := range []bool{false, true} {
+			stmts, err := parser.ParseStmts(input)
+			if err != nil {
+				t.Fatalf("test [%d]: parse failed. error: %s", i, err)
+			}
+			initEnv()
+			SetOptimize(enabled)
+			var buf bytes.Buffer
+			SetOutput(&buf)
+			err = Execute(stmts)
+			SetOutput(nil)
+			results[j] = fmt.Sprintf("%s%v", buf.String(), err)
+		}
+		if results[0] != results[1] {
+			t.Errorf("test [%d]: optimized program behaves differently.\nexpected=%q\ngot=%q", i, results[0], results[1])
+		}
+	}
+}
+
+func benchmarkProgram(b *testing.B, input string) {
+	stmts, err := parser.ParseStmts(input)
+	if err != nil {
+		b.Fatalf("parse failed. error: %s", err)
+	}
+	initEnv()
+	SetOutput(ioutil.Discard)
+	defer SetOutput(nil)
+	if err := Execute(stmts); err != nil {
+		b.Fatal(err)
+	}
+	loop, err := parser.ParseStmts("run();")
+	if err != nil {
+		b.Fatalf("parse failed. error: %s", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Execute(loop); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+const benchmarkClass = `class Point {
+  init(x, y) { this.x = x; this.y = y; }
+  sum() { return this.x + this.y; }
+}
+var p = Point(1, 2);
+`
+
+func BenchmarkFieldGet(b *testing.B) {
+	benchmarkProgram(b, benchmarkClass+"fun run() { for (var i = 0; i < 100; i = i + 1) p.x; }")
+}
+
+func BenchmarkFieldSet(b *testing.B) {
+	benchmarkProgram(b, benchmarkClass+"fun run() { for (var i = 0; i < 100; i = i + 1) p.x = i; }")
+}
+
+func BenchmarkMethodCall(b *testing.B) {
+	benchmarkProgram(b, benchmarkClass+"fun run() { for (var i = 0; i < 100; i = i + 1) p.sum(); }")
+}
+
+func BenchmarkConstruct(b *testing.B) {
+	benchmarkProgram(b, benchmarkClass+"fun run() { for (var i = 0; i < 100; i = i + 1) Point(i, i); }")
+}
+
+func BenchmarkExecute(b *testing.B) {
+	SetOutput(ioutil.Discard)
+	defer SetOutput(nil)
+	workloads, err := workload.Load("../bench/testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, w := range workloads {
+		statements, err := parser.ParseStmts(w.Src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(w.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				initEnv()
+				if err := Execute(statements); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
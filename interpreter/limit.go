@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/errors"
+	"github.com/ziyoung/lox-go/token"
+)
+
+// DefaultDepth is the default maximum depth of the call stack. Deeper recursion
+// is reported as a stack overflow instead of exhausting the Go stack.
+const DefaultDepth = 10000
+
+// Limits bounds the execution of a program. A zero field means no limit.
+type Limits struct {
+	// Steps is the maximum number of statements executed by Execute or Interpret.
+	Steps int
+	// Depth is the maximum depth of the call stack.
+	Depth int
+}
+
+var (
+	limits = Limits{Depth: DefaultDepth}
+	// steps is the number of statements executed since Execute or Interpret was called.
+	steps int
+)
+
+// SetLimits sets the limits of execution. It is used to run untrusted programs.
+func SetLimits(l Limits) {
+	limits = l
+}
+
+// step counts stmt as executed and reports an error when the step limit is exceeded.
+func step(stmt ast.Stmt) {
+	steps++
+	if limits.Steps > 0 && steps > limits.Steps {
+		errors.ErrorAt(stmt.Pos(), token.EOF, "Execution step limit exceeded.")
+	}
+}
+
+// checkDepth reports an error when calling a function would exceed the depth limit.
+func checkDepth() {
+	if limits.Depth > 0 && len(frames) >= limits.Depth {
+		errors.Error(token.LeftParen, "Stack overflow.")
+	}
+}
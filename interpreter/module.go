@@ -0,0 +1,195 @@
+package interpreter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/errors"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/resolver"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+var (
+	// path are directories searched for modules which are not found relative to
+	// the importing file.
+	path []string
+	// resolve maps an import path to a file, such as a vendored package. It may be nil.
+	resolve func(name string) (string, bool)
+	// importing is the chain of files being executed, the main file first.
+	// An empty name stands for a program which isn't read from a file.
+	importing = []string{""}
+	// modules caches loaded modules by their absolute file names.
+	modules = make(map[string]*valuer.Module)
+)
+
+// SetPath sets directories searched for modules, after the directory of the importing file.
+func SetPath(dirs []string) {
+	path = dirs
+}
+
+// SetResolver sets a function mapping import paths which are not found relative to
+// the importing file to files, before directories set by SetPath are searched. It is
+// used to resolve imports of packages. A nil r removes the resolver.
+func SetResolver(r func(name string) (file string, ok bool)) {
+	resolve = r
+}
+
+// SetFile sets the file of the program to be executed. Modules it imports are looked
+// up relative to its directory. An empty name means the current directory.
+func SetFile(name string) {
+	if name != "" {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	importing = []string{name}
+}
+
+// findModule returns the absolute name of the file imported as name.
+func findModule(name string) (string, bool) {
+	if filepath.Ext(name) != ".lox" {
+		name += ".lox"
+	}
+	if filepath.IsAbs(name) {
+		return name, isFile(name)
+	}
+	if file, err := filepath.Abs(filepath.Join(filepath.Dir(importing[len(importing)-1]), name)); err == nil && isFile(file) {
+		return file, true
+	}
+	if resolve != nil {
+		if file, ok := resolve(name); ok {
+			if abs, err := filepath.Abs(file); err == nil {
+				return abs, true
+			}
+		}
+	}
+	for _, dir := range path {
+		file, err := filepath.Abs(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if isFile(file) {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+func isFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.IsDir()
+}
+
+// fileOf returns the file declaring function.
+func fileOf(function *valuer.Function) string {
+	if function.Module != nil {
+		return function.Module.File
+	}
+	return importing[0]
+}
+
+// displayName returns file relative to the current directory if possible.
+func displayName(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return file
+}
+
+func evalImportStmt(stmt *ast.ImportStmt) {
+	m := importModule(stmt)
+	if len(stmt.Names) == 0 {
+		env.Define(stmt.Name.Name, m)
+		return
+	}
+	for _, name := range stmt.Names {
+		v, ok := m.Get(name.Name)
+		if !ok {
+			errors.ErrorAt(name.NamePos, token.Identifier, fmt.Sprintf("Module %s has no member %s.", m.Name, name.Name))
+		}
+		env.Define(name.Name, v)
+	}
+}
+
+// importModule returns the module imported by stmt, which is loaded the first time
+// it is imported.
+func importModule(stmt *ast.ImportStmt) *valuer.Module {
+	file, ok := findModule(stmt.Path)
+	if !ok {
+		errors.ErrorAt(stmt.PathPos, token.String, fmt.Sprintf("Cannot find module %s.", stmt.Path))
+	}
+	if m, ok := modules[file]; ok {
+		return m
+	}
+	for i, f := range importing {
+		if f == file {
+			chain := make([]string, 0, len(importing)-i+1)
+			for _, f := range append(importing[i:], file) {
+				chain = append(chain, displayName(f))
+			}
+			errors.ErrorAt(stmt.ImportPos, token.Import, "Import cycle: "+strings.Join(chain, " -> ")+".")
+		}
+	}
+
+	m := &valuer.Module{Name: stmt.Path, File: file, Globals: valuer.NewEnclosing(builtins)}
+	if err := loadModule(m); err != nil {
+		msg := err.Error()
+		if perr, ok := err.(interface {
+			Pos() token.Position
+			Message() string
+		}); ok && perr.Pos().IsValid() {
+			msg = fmt.Sprintf("%s:%s: %s", displayName(file), perr.Pos(), perr.Message())
+		}
+		errors.ErrorAt(stmt.ImportPos, token.Import, msg)
+	}
+	modules[file] = m
+	return m
+}
+
+// loadModule executes the file of m in the global environment of m.
+func loadModule(m *valuer.Module) (err error) {
+	src, err := ioutil.ReadFile(m.File)
+	if err != nil {
+		return err
+	}
+	statements, err := parser.ParseStmts(string(src))
+	if err != nil {
+		return err
+	}
+
+	previousGlobals, previousEnv, previousModule := globals, env, module
+	importing = append(importing, m.File)
+	defer func() {
+		globals, env, module = previousGlobals, previousEnv, previousModule
+		importing = importing[:len(importing)-1]
+	}()
+	defer catch(&err, len(frames))
+	resolver.Reset()
+	for _, stmt := range statements {
+		resolver.Resolve(stmt)
+	}
+	statements = optimize(statements)
+	globals, env, module = m.Globals, m.Globals, m
+	pushFrame("<module "+m.Name+">", m.File, env)
+	for _, stmt := range statements {
+		execute(stmt)
+	}
+	popFrame()
+	return nil
+}
+
+// globalsOf returns the global environment of the module where environment is created.
+func globalsOf(environment *valuer.Environment) *valuer.Environment {
+	for environment.Enclosing != nil && environment.Enclosing != builtins {
+		environment = environment.Enclosing
+	}
+	return environment
+}
@@ -0,0 +1,24 @@
+package interpreter
+
+import (
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/optimizer"
+)
+
+// optimizing is whether programs are optimized after they are resolved.
+var optimizing = true
+
+// SetOptimize enables or disables the optimizer, which is enabled by default.
+// Programs are not optimized while a statement or branch hook is set, so that
+// debuggers, profilers and coverage tools see them as written.
+func SetOptimize(enabled bool) {
+	optimizing = enabled
+}
+
+// optimize optimizes resolved statements if the optimizer is enabled.
+func optimize(statements []ast.Stmt) []ast.Stmt {
+	if !optimizing || hook != nil || branchHook != nil {
+		return statements
+	}
+	return optimizer.Optimize(statements)
+}
@@ -0,0 +1,60 @@
+package lexer
+
+import (
+	"strings"
+
+	"github.com/ziyoung/lox-go/token"
+)
+
+// Item is a token read by All.
+type Item struct {
+	Token   token.Token
+	Literal string
+	Pos     token.Position
+	// End is the position right after the token.
+	End token.Position
+	// Text is the source text of the token.
+	Text string
+	// Leading is the whitespace and comments before the token, if trivia is kept.
+	Leading []Trivia
+}
+
+// Trivia is a run of whitespace or a comment.
+type Trivia struct {
+	Pos  token.Position
+	Text string
+}
+
+// IsComment reports whether t is a comment.
+func (t Trivia) IsComment() bool { return strings.HasPrefix(t.Text, "//") }
+
+// All returns an iterator over the tokens of src, which ends with token.EOF.
+// Iteration stops early if yield returns false.
+//
+// If trivia is true, comments are not yielded as tokens but kept with whitespace
+// in Leading of the next token, and the trivia at the end of src is kept by the
+// EOF token. Concatenating Leading and Text of every item then gives back src.
+func All(src string, trivia bool) func(yield func(Item) bool) {
+	return func(yield func(Item) bool) {
+		l := New(src)
+		end := l.pos
+		var leading []Trivia
+		for {
+			tok, literal := l.NextToken()
+			pos := l.TokenPos()
+			if trivia && pos.Offset > end.Offset {
+				leading = append(leading, Trivia{Pos: end, Text: src[end.Offset:pos.Offset]})
+			}
+			item := Item{Token: tok, Literal: literal, Pos: pos, End: l.pos, Text: src[pos.Offset:l.pos.Offset]}
+			end = l.pos
+			if trivia && tok == token.Comment {
+				leading = append(leading, Trivia{Pos: item.Pos, Text: item.Text})
+				continue
+			}
+			item.Leading, leading = leading, nil
+			if !yield(item) || tok == token.EOF {
+				return
+			}
+		}
+	}
+}
@@ -29,12 +29,23 @@ type Lexer struct {
 	s      *scanner.Scanner
 	ch     rune
 	tokBuf *strings.Builder
+
+	// pos is position of ch.
+	pos token.Position
+	// tokPos is start position of the last token.
+	tokPos token.Position
+	// braces holds the number of unclosed braces in each embedded expression of
+	// strings being read, the innermost last. The "}" closing an expression
+	// continues its string.
+	braces []int
 }
 
 func (l *Lexer) consume() {
 	if l.isAtEnd() {
 		return
 	}
+	pos := l.s.Pos()
+	l.pos = token.Position{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
 	ch := l.s.Next()
 	if ch == scanner.EOF {
 		l.ch = eof
@@ -81,35 +92,52 @@ func (l *Lexer) readIdentifier() string {
 	return l.tokBuf.String()
 }
 
-func (l *Lexer) readString() (string, error) {
+func (l *Lexer) readComment() string {
+	l.tokBuf.Reset()
+	for l.ch != '\n' && !l.isAtEnd() {
+		l.tokBuf.WriteRune(l.ch)
+		l.consume()
+	}
+	return strings.TrimRight(l.tokBuf.String(), "\r")
+}
+
+// readString reads a string from its opening quote, or a part of a string after an
+// embedded expression from the closing "}". interpolated reports whether the part
+// ends with "${" starting an embedded expression.
+func (l *Lexer) readString() (s string, interpolated bool, err error) {
 	l.tokBuf.Reset()
 	l.consume()
 	if l.ch == '"' {
 		l.consume()
-		return "", nil
+		return "", false, nil
 	}
 
 	for l.ch != '"' {
+		if l.ch == '$' && l.peek() == '{' {
+			l.consume()
+			l.consume()
+			return l.tokBuf.String(), true, nil
+		}
 		if l.isAtEnd() {
 			l.error(errUnterminated.Error())
-			return "", errUnterminated
+			return "", false, errUnterminated
 		} else if l.ch == '\\' {
 			peekCh := l.peek()
 			if peekCh == eof {
 				l.error(errEspace.Error())
-				return "", errEspace
+				return "", false, errEspace
 			}
 			l.consume()
 			switch peekCh {
-			case '"':
-				l.tokBuf.WriteRune('"')
+			case '"', '$':
+				l.tokBuf.WriteRune(peekCh)
 			case 'u':
 				code := make([]rune, 4)
 				for i := range code {
 					l.consume()
 					if !unicode.Is(unicode.Hex_Digit, l.ch) {
 						l.error(errInvalidChar.Error())
-						return "", errInvalidChar
+						return "", false, errInvalidChar
 					}
 					code[i] = l.ch
 				}
@@ -122,24 +150,38 @@ func (l *Lexer) readString() (string, error) {
 	}
 	// end ".
 	l.consume()
-	return l.tokBuf.String(), nil
+	return l.tokBuf.String(), false, nil
+}
+
+// stringToken reads a string, or a part of it, as token.Interpolation if an
+// embedded expression follows and as token.String otherwise.
+func (l *Lexer) stringToken() (token.Token, string) {
+	s, interpolated, err := l.readString()
+	if err != nil {
+		return token.Illegal, s
+	}
+	if interpolated {
+		l.braces = append(l.braces, 0)
+		return token.Interpolation, s
+	}
+	return token.String, s
 }
 
 func (l *Lexer) readNumber() (string, error) {
 
 	l.tokBuf.Reset()
-	for unicode.IsNumber(l.ch) {
+	for isDigit(l.ch) {
 		l.tokBuf.WriteRune(l.ch)
 		l.consume()
 	}
 
 	if l.ch == '.' {
-		if !unicode.IsNumber(l.peek()) {
+		if !isDigit(l.peek()) {
 			return l.tokBuf.String(), nil
 		}
 		l.tokBuf.WriteRune(l.ch)
 		l.consume()
-		for unicode.IsNumber(l.ch) {
+		for isDigit(l.ch) {
 			l.tokBuf.WriteRune(l.ch)
 			l.consume()
 		}
@@ -153,7 +195,7 @@ func (l *Lexer) readNumber() (string, error) {
 			l.tokBuf.WriteRune(l.ch)
 			l.consume()
 		}
-		for unicode.IsNumber(l.ch) {
+		for isDigit(l.ch) {
 			seenPower = true
 			l.tokBuf.WriteRune(l.ch)
 			l.consume()
@@ -170,8 +212,10 @@ func (l *Lexer) readNumber() (string, error) {
 // NextToken reads and returns token and literal.
 // It returns token.Illegal for invalid string or number.
 // It return token.EOF at the end of input string.
+// Comments are returned as token.Comment with the text including "//".
 func (l *Lexer) NextToken() (tok token.Token, literal string) {
 	l.skip()
+	l.tokPos = l.pos
 
 	switch l.ch {
 	case '(':
@@ -181,9 +225,19 @@ func (l *Lexer) NextToken() (tok token.Token, literal string) {
 		tok = token.RightParen
 		literal = ")"
 	case '{':
+		if n := len(l.braces); n > 0 {
+			l.braces[n-1]++
+		}
 		tok = token.LeftBrace
 		literal = "{"
 	case '}':
+		if n := len(l.braces); n > 0 {
+			if l.braces[n-1] == 0 {
+				l.braces = l.braces[:n-1]
+				return l.stringToken()
+			}
+			l.braces[n-1]--
+		}
 		tok = token.RightBrace
 		literal = "}"
 	case ',':
@@ -202,6 +256,9 @@ func (l *Lexer) NextToken() (tok token.Token, literal string) {
 		tok = token.Semicolon
 		literal = ";"
 	case '/':
+		if l.peek() == '/' {
+			return token.Comment, l.readComment()
+		}
 		tok = token.Slash
 		literal = "/"
 	case '*':
@@ -244,22 +301,16 @@ func (l *Lexer) NextToken() (tok token.Token, literal string) {
 		}
 		return
 	case '"':
-		liter, err := l.readString()
-		if err != nil {
-			return token.Illegal, liter
-		}
-		tok = token.String
-		literal = liter
-		return
+		return l.stringToken()
 	case eof:
 		tok = token.EOF
 		return
 	default:
-		if unicode.IsLetter(l.ch) {
+		if unicode.IsLetter(l.ch) || l.ch == '_' {
 			literal = l.readIdentifier()
 			tok = token.Lookup(literal)
 			return
-		} else if unicode.IsNumber(l.ch) {
+		} else if isDigit(l.ch) {
 			liter, err := l.readNumber()
 			if err != nil {
 				return token.Illegal, ""
@@ -277,6 +328,11 @@ func (l *Lexer) NextToken() (tok token.Token, literal string) {
 	return
 }
 
+// TokenPos returns start position of the last token returned by NextToken.
+func (l *Lexer) TokenPos() token.Position {
+	return l.tokPos
+}
+
 // Pos returns current position of lexer.
 func (l *Lexer) Pos() scanner.Position {
 	return l.s.Pos()
@@ -290,6 +346,8 @@ func charCode2Rune(code string) rune {
 	return rune(v)
 }
 
+func isDigit(ch rune) bool { return '0' <= ch && ch <= '9' }
+
 func isAlphaNumeric(ch rune) bool { return unicode.IsLetter(ch) || unicode.IsNumber(ch) || ch == '_' }
 
 // New return an instance of Lexer.
@@ -1,8 +1,13 @@
 package lexer
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/ziyoung/lox-go/bench/workload"
 	"github.com/ziyoung/lox-go/token"
 )
 
@@ -105,6 +110,45 @@ func TestReadInvalidString(t *testing.T) {
 	}
 }
 
+func TestReadInterpolation(t *testing.T) {
+	input := `"x = ${x}, y = ${ {}.y + "(${f("}")})" }!" "\${a}" "${"${b}"}"`
+	tests := []struct {
+		expectTok     token.Token
+		expectLiteral string
+	}{
+		{token.Interpolation, "x = "},
+		{token.Identifier, "x"},
+		{token.Interpolation, ", y = "},
+		{token.LeftBrace, "{"},
+		{token.RightBrace, "}"},
+		{token.Dot, "."},
+		{token.Identifier, "y"},
+		{token.Plus, "+"},
+		{token.Interpolation, "("},
+		{token.Identifier, "f"},
+		{token.LeftParen, "("},
+		{token.String, "}"},
+		{token.RightParen, ")"},
+		{token.String, ")"},
+		{token.String, "!"},
+		{token.String, "${a}"},
+		{token.Interpolation, ""},
+		{token.Interpolation, ""},
+		{token.Identifier, "b"},
+		{token.String, ""},
+		{token.String, ""},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, test := range tests {
+		tok, literal := l.NextToken()
+		if tok != test.expectTok || literal != test.expectLiteral {
+			t.Fatalf("test [%d]: expected %s %q. got %s %q", i, test.expectTok, test.expectLiteral, tok, literal)
+		}
+	}
+}
+
 func TestReadIdentifier(t *testing.T) {
 	input := `
 abc 		xyz 		a123 		A_123			X_x_
@@ -202,6 +246,7 @@ func TestReadInvalidNumber(t *testing.T) {
 		"123E.",
 		"123.45e-",
 		"123.45e+",
+		"٣",
 	}
 
 	for i, test := range tests {
@@ -217,3 +262,160 @@ func TestReadInvalidNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestReadComment(t *testing.T) {
+	input := `// comment
+a / b // tail
+//`
+	tests := []struct {
+		expectTok     token.Token
+		expectLiteral string
+	}{
+		{token.Comment, "// comment"},
+		{token.Identifier, "a"},
+		{token.Slash, "/"},
+		{token.Identifier, "b"},
+		{token.Comment, "// tail"},
+		{token.Comment, "//"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+
+	for i, test := range tests {
+		tok, literal := l.NextToken()
+		if tok != test.expectTok {
+			t.Fatalf("test [%d]: expected token is %s. got %s", i, test.expectTok, tok)
+		}
+		if literal != test.expectLiteral {
+			t.Fatalf("test [%d]: expected literal is %q. got %q", i, test.expectLiteral, literal)
+		}
+	}
+}
+
+func TestTokenPos(t *testing.T) {
+	input := `var a = "x";
+  print a;`
+	expected := []string{"1:1", "1:5", "1:7", "1:9", "1:12", "2:3", "2:9", "2:10"}
+	l := New(input)
+
+	for i, pos := range expected {
+		l.NextToken()
+		if l.TokenPos().String() != pos {
+			t.Fatalf("test [%d]: expected position is %s. got %s", i, pos, l.TokenPos())
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	input := "// head\nvar a = \"x\"; // tail\n\n  print a /\tb;\n"
+	expected := []struct {
+		tok     token.Token
+		text    string
+		pos     string
+		leading []string
+	}{
+		{token.Var, "var", "2:1", []string{"// head", "\n"}},
+		{token.Identifier, "a", "2:5", []string{" "}},
+		{token.Equal, "=", "2:7", []string{" "}},
+		{token.String, `"x"`, "2:9", []string{" "}},
+		{token.Semicolon, ";", "2:12", nil},
+		{token.Print, "print", "4:3", []string{" ", "// tail", "\n\n  "}},
+		{token.Identifier, "a", "4:9", []string{" "}},
+		{token.Slash, "/", "4:11", []string{" "}},
+		{token.Identifier, "b", "4:13", []string{"\t"}},
+		{token.Semicolon, ";", "4:14", nil},
+		{token.EOF, "", "5:1", []string{"\n"}},
+	}
+	var items []Item
+	All(input, true)(func(item Item) bool {
+		items = append(items, item)
+		return true
+	})
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d tokens. got %d", len(expected), len(items))
+	}
+	for i, test := range expected {
+		item := items[i]
+		var leading []string
+		for _, tr := range item.Leading {
+			leading = append(leading, tr.Text)
+		}
+		if item.Token != test.tok || item.Text != test.text || item.Pos.String() != test.pos ||
+			!reflect.DeepEqual(leading, test.leading) {
+			t.Errorf("test [%d]: expected %s %q at %s with %q. got %s %q at %s with %q", i,
+				test.tok, test.text, test.pos, test.leading, item.Token, item.Text, item.Pos, leading)
+		}
+	}
+	if !items[5].Leading[1].IsComment() || items[5].Leading[0].IsComment() {
+		t.Errorf("comments are not reported")
+	}
+	if s := rebuild(input); s != input {
+		t.Errorf("source is not rebuilt. got %q", s)
+	}
+
+	var tokens []token.Token
+	All(input, false)(func(item Item) bool {
+		tokens = append(tokens, item.Token)
+		return item.Token != token.Semicolon
+	})
+	if !reflect.DeepEqual(tokens, []token.Token{token.Comment, token.Var, token.Identifier, token.Equal, token.String, token.Semicolon}) {
+		t.Errorf("tokens wrong without trivia. got %v", tokens)
+	}
+}
+
+// rebuild returns the source of tokens and trivia of src.
+func rebuild(src string) string {
+	var b strings.Builder
+	All(src, true)(func(item Item) bool {
+		for _, tr := range item.Leading {
+			b.WriteString(tr.Text)
+		}
+		b.WriteString(item.Text)
+		return true
+	})
+	return b.String()
+}
+
+func FuzzNextToken(f *testing.F) {
+	files, err := filepath.Glob("../example/*.lox")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(b))
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if s := rebuild(input); s != input {
+			t.Fatalf("source is not rebuilt. got %q", s)
+		}
+		l := New(input)
+		// Every token but EOF consumes at least one character.
+		for i := 0; i <= len(input); i++ {
+			if tok, _ := l.NextToken(); tok == token.EOF {
+				return
+			}
+		}
+		t.Fatalf("no EOF after %d tokens", len(input)+1)
+	})
+}
+
+func BenchmarkLex(b *testing.B) {
+	workloads, err := workload.Load("../bench/testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, w := range workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			b.SetBytes(int64(len(w.Src)))
+			for i := 0; i < b.N; i++ {
+				l := New(w.Src)
+				for tok, _ := l.NextToken(); tok != token.EOF; tok, _ = l.NextToken() {
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package lint reports suspicious constructs in Lox programs.
+//
+// A warning is suppressed by a comment "// lint:ignore CODE[,CODE]" on the
+// same line or on the line above. "// lint:ignore" without codes suppresses all warnings.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/resolver"
+	"github.com/ziyoung/lox-go/token"
+)
+
+// Codes of warnings.
+const (
+	UnusedVariable   = "L001"
+	UnusedParameter  = "L002"
+	Shadow           = "L003"
+	UndeclaredAssign = "L004"
+	Unreachable      = "L005"
+	ArgumentCount    = "L006"
+	FunctionThis     = "L007"
+)
+
+const ignoreDirective = "lint:ignore"
+
+// Warning represents a problem found by the linter.
+type Warning struct {
+	Pos     token.Position
+	Code    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s %s", w.Pos, w.Code, w.Message)
+}
+
+// Source parses src and checks it.
+func Source(src []byte) ([]Warning, error) {
+	p := parser.New(lexer.New(string(src)))
+	statements, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return Check(statements, p.Comments())
+}
+
+// Check checks statements. Comments are used for suppressing warnings.
+// An error is returned if statements can't be resolved.
+func Check(statements []ast.Stmt, comments []*ast.Comment) ([]Warning, error) {
+	info, err := resolver.Analyze(statements)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []Warning
+	report := func(pos token.Position, code, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Pos: pos, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, b := range info.Bindings {
+		if !b.Global && len(b.Reads) == 0 {
+			if b.Kind == resolver.ParamBinding {
+				report(b.Pos, UnusedParameter, "parameter %s is never used", b.Name)
+			} else {
+				report(b.Pos, UnusedVariable, "%s %s is never used", b.Kind, b.Name)
+			}
+		}
+		if b.Shadows != nil {
+			report(b.Pos, Shadow, "%s %s shadows %s declared at %s", b.Kind, b.Name, b.Shadows.Kind, b.Shadows.Pos)
+		}
+	}
+	for _, assign := range info.Undeclared {
+		report(assign.Pos(), UndeclaredAssign, "assignment to undeclared global %s", assign.Left.Name)
+	}
+	for _, call := range info.Calls {
+		checkCall(info, call, report)
+	}
+	for _, stmt := range info.Unreachable {
+		report(stmt.Pos(), Unreachable, "unreachable code")
+	}
+	for _, expr := range info.FunctionThis {
+		report(expr.Pos(), FunctionThis, "this is captured by a function which is not a method")
+	}
+
+	warnings = suppress(warnings, comments)
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Pos.Offset < warnings[j].Pos.Offset
+	})
+	return warnings, nil
+}
+
+// checkCall checks arity of calls to functions and classes which are never reassigned.
+func checkCall(info *resolver.Info, call *ast.CallExpr, report func(token.Position, string, string, ...interface{})) {
+	callee, ok := call.Callee.(*ast.VariableExpr)
+	if !ok {
+		return
+	}
+	b := info.Uses[callee]
+	if b == nil || len(b.Writes) != 0 {
+		return
+	}
+	arity := -1
+	switch decl := b.Decl.(type) {
+	case *ast.FunctionStmt:
+		arity = len(decl.Params)
+	case *ast.ClassStmt:
+		arity = 0
+		for _, method := range decl.Methods {
+			if method.IsInitializer {
+				arity = len(method.Params)
+			}
+		}
+	}
+	if arity >= 0 && arity != len(call.Arguments) {
+		report(call.Pos(), ArgumentCount, "%s expects %d arguments but got %d", b.Name, arity, len(call.Arguments))
+	}
+}
+
+// suppress removes warnings suppressed by comments.
+func suppress(warnings []Warning, comments []*ast.Comment) []Warning {
+	// ignored maps line to suppressed codes. An empty slice suppresses all codes.
+	ignored := make(map[int][]string)
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(text, ignoreDirective) {
+			continue
+		}
+		var codes []string
+		for _, code := range strings.Split(strings.TrimSpace(text[len(ignoreDirective):]), ",") {
+			if code = strings.TrimSpace(code); code != "" {
+				codes = append(codes, code)
+			}
+		}
+		if codes == nil {
+			codes = []string{}
+		}
+		line := c.Slash.Line
+		for _, l := range []int{line, line + 1} {
+			if prev, ok := ignored[l]; ok && (len(prev) == 0 || len(codes) == 0) {
+				ignored[l] = []string{}
+			} else {
+				ignored[l] = append(prev[:len(prev):len(prev)], codes...)
+			}
+		}
+	}
+
+	result := warnings[:0]
+	for _, w := range warnings {
+		codes, ok := ignored[w.Pos.Line]
+		if ok && (len(codes) == 0 || contains(codes, w.Code)) {
+			continue
+		}
+		result = append(result, w)
+	}
+	return result
+}
+
+func contains(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
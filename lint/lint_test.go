@@ -0,0 +1,47 @@
+package lint
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"var a = 1; print a;", nil},
+		{"{ var a = 1; }", []string{"1:7: L001 variable a is never used"}},
+		{"fun f(a, b) { return a; }", []string{"1:10: L002 parameter b is never used"}},
+		{"var a; { var a = 1; print a; }", []string{"1:14: L003 variable a shadows variable declared at 1:5"}},
+		{"fun f(a) { { var a = 1; print a; } return a; }", []string{"1:18: L003 variable a shadows parameter declared at 1:7"}},
+		{"a = 1;", []string{"1:1: L004 assignment to undeclared global a"}},
+		{"var a; a = 1;", nil},
+		{"fun f() { return 1; print 2; }", []string{"1:21: L005 unreachable code"}},
+		{"fun f(a) { return a; } f(1, 2);", []string{"1:24: L006 f expects 1 arguments but got 2"}},
+		{"class A { init(x) { this.x = x; } } A();", []string{"1:37: L006 A expects 1 arguments but got 0"}},
+		{"fun f(a) { return a; } f = clock; f();", nil},
+		{"class A { m() { fun g() { return this; } return g; } }", []string{"1:34: L007 this is captured by a function which is not a method"}},
+		{"{ var a = 1; } // lint:ignore L001", nil},
+		{"// lint:ignore\n{ var a = 1; }", nil},
+		{"{ var a = 1; } // lint:ignore L002", []string{"1:7: L001 variable a is never used"}},
+		{"// lint:ignore L001\n{ var a = 1; } // lint:ignore L002", nil},
+		{"// lint:ignore\n{ var a = 1; } // lint:ignore L002", nil},
+		{"// lint:ignore L002\n// lint:ignore L003\n{ var a = 1; }", []string{"3:7: L001 variable a is never used"}},
+	}
+
+	for i, test := range tests {
+		warnings, err := Source([]byte(test.input))
+		if err != nil {
+			t.Fatalf("test [%d]: lint failed. error: %s", i, err.Error())
+		}
+		if len(warnings) != len(test.expected) {
+			t.Errorf("test [%d]: expected %d warnings. got %v", i, len(test.expected), warnings)
+			continue
+		}
+		for j, w := range warnings {
+			if w.String() != test.expected[j] {
+				t.Errorf("test [%d]: expected warning is %q. got %q", i, test.expected[j], w.String())
+			}
+		}
+	}
+}
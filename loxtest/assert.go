@@ -0,0 +1,75 @@
+package loxtest
+
+import (
+	"fmt"
+
+	"github.com/ziyoung/lox-go/errors"
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// Asserts are the functions of the assert module.
+//
+//	assertEqual(expected, actual)  fails unless actual equals expected.
+//	assertTrue(value)              fails unless value is true.
+//	assertThrows(fn)               calls fn, and fails unless it reports a runtime error.
+//	                               The error message is returned.
+var Asserts = []*valuer.NativeFunction{
+	{Name: "assertEqual", NumParams: 2, Fn: assertEqual},
+	{Name: "assertTrue", NumParams: 1, Fn: assertTrue},
+	{Name: "assertThrows", NumParams: 1, Fn: assertThrows},
+}
+
+// DefineAsserts defines Asserts in the global environment.
+func DefineAsserts() {
+	for _, fn := range Asserts {
+		interpreter.Define(fn.Name, fn)
+	}
+}
+
+// fail reports an assertion failure at the statement calling the assertion.
+func fail(format string, a ...interface{}) {
+	var pos token.Position
+	if frames := interpreter.Frames(); len(frames) > 0 {
+		pos = frames[0].Pos
+	}
+	errors.ErrorAt(pos, token.LeftParen, fmt.Sprintf(format, a...))
+}
+
+// display formats v in failure messages. Strings are quoted to tell them from other values.
+func display(v valuer.Valuer) string {
+	if s, ok := v.(*valuer.String); ok {
+		return fmt.Sprintf("%q", s.Value)
+	}
+	return v.String()
+}
+
+func assertEqual(args []valuer.Valuer) (valuer.Valuer, error) {
+	expected, actual := args[0], args[1]
+	if expected != actual && (expected.Type() != actual.Type() || !valuer.Equal(expected, actual)) {
+		fail("assertEqual: expected %s, got %s", display(expected), display(actual))
+	}
+	return nil, nil
+}
+
+func assertTrue(args []valuer.Valuer) (valuer.Valuer, error) {
+	if b, ok := args[0].(*valuer.Boolean); !ok || !b.Value {
+		fail("assertTrue: got %s", display(args[0]))
+	}
+	return nil, nil
+}
+
+func assertThrows(args []valuer.Valuer) (valuer.Valuer, error) {
+	if _, ok := args[0].(valuer.Callable); !ok {
+		fail("assertThrows: %s is not callable", display(args[0]))
+	}
+	_, err := interpreter.CallValue(args[0])
+	if err == nil {
+		fail("assertThrows: no error")
+	}
+	if runErr, ok := err.(*errors.RuntimeError); ok {
+		return &valuer.String{Value: runErr.Message()}, nil
+	}
+	return &valuer.String{Value: err.Error()}, nil
+}
@@ -0,0 +1,75 @@
+package loxtest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+	Error    *junitError `xml:"error,omitempty"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitError struct {
+	Message string `xml:"message,attr"`
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes suites as a JUnit XML report. The error of a file without
+// tests is reported as an error of its suite.
+func WriteJUnit(w io.Writer, suites []*Suite) error {
+	report := junitSuites{}
+	for _, s := range suites {
+		js := junitSuite{Name: s.File, Tests: len(s.Tests), Failures: s.Failures(), Time: seconds(s.Time)}
+		if s.Err != nil {
+			js.Errors = 1
+			js.Error = &junitError{Message: s.Message(s.Err)}
+		}
+		for _, r := range s.Tests {
+			jc := junitCase{Name: r.Name, ClassName: s.File, Time: seconds(r.Time)}
+			if r.Err != nil {
+				msg := s.Message(r.Err)
+				jc.Failure = &junitFailure{Message: msg, Text: msg}
+			}
+			js.Cases = append(js.Cases, jc)
+		}
+		report.Suites = append(report.Suites, js)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,118 @@
+// Package loxtest runs tests written in Lox.
+//
+// Test files are named *_test.lox. Tests are top-level functions whose names
+// start with "test" and take no parameters. Each test runs in a fresh global
+// environment: the top-level code of the file is executed with the assert
+// functions defined, and then the test function is called. A test fails when
+// it reports a runtime error, such as a failed assertion.
+package loxtest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/errors"
+	"github.com/ziyoung/lox-go/interpreter"
+)
+
+// IsTestFile reports whether the file name is a test file.
+func IsTestFile(name string) bool {
+	return strings.HasSuffix(filepath.Base(name), "_test.lox")
+}
+
+// Tests returns test functions declared at the top level of statements.
+func Tests(statements []ast.Stmt) []*ast.FunctionStmt {
+	var tests []*ast.FunctionStmt
+	for _, stmt := range statements {
+		if fn, ok := stmt.(*ast.FunctionStmt); ok && strings.HasPrefix(fn.Name, "test") && len(fn.Params) == 0 {
+			tests = append(tests, fn)
+		}
+	}
+	return tests
+}
+
+// Result is the result of a test.
+type Result struct {
+	Name string
+	// Err is the error failing the test, nil if the test passes.
+	Err  error
+	Time time.Duration
+}
+
+// Suite is the result of the tests of a file.
+type Suite struct {
+	File  string
+	Tests []*Result
+	// Err is the error of the top-level code of a file without tests.
+	Err  error
+	Time time.Duration
+}
+
+// Failed reports whether any test of the suite fails.
+func (s *Suite) Failed() bool {
+	if s.Err != nil {
+		return true
+	}
+	for _, r := range s.Tests {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Failures returns the number of failed tests.
+func (s *Suite) Failures() int {
+	n := 0
+	for _, r := range s.Tests {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Message formats err with the file and position where it occurs.
+func (s *Suite) Message(err error) string {
+	if runErr, ok := err.(*errors.RuntimeError); ok && runErr.Pos().IsValid() {
+		return fmt.Sprintf("%s:%s: %s", s.File, runErr.Pos(), runErr.Message())
+	}
+	return fmt.Sprintf("%s: %s", s.File, err)
+}
+
+// Run runs the tests of file, which is parsed into statements. A file without
+// tests is run as a script.
+func Run(file string, statements []ast.Stmt) *Suite {
+	start := time.Now()
+	suite := &Suite{File: file}
+	interpreter.SetFile(file)
+	tests := Tests(statements)
+	if len(tests) == 0 {
+		suite.Err = setup(statements)
+	}
+	for _, test := range tests {
+		suite.Tests = append(suite.Tests, runTest(test, statements))
+	}
+	suite.Time = time.Since(start)
+	return suite
+}
+
+// setup executes statements in a fresh environment with the assert functions.
+func setup(statements []ast.Stmt) error {
+	interpreter.Reset()
+	DefineAsserts()
+	return interpreter.Execute(statements)
+}
+
+func runTest(test *ast.FunctionStmt, statements []ast.Stmt) *Result {
+	start := time.Now()
+	r := &Result{Name: test.Name}
+	if r.Err = setup(statements); r.Err == nil {
+		_, r.Err = interpreter.Call(test.Name)
+	}
+	r.Time = time.Since(start)
+	return r
+}
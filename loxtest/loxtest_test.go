@@ -0,0 +1,123 @@
+package loxtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+const source = `var count = 0;
+fun add(a, b) { return a + b; }
+fun negate(x) { return -x; }
+
+fun testAdd() {
+  count = count + 1;
+  assertEqual(3, add(1, 2));
+  assertTrue(count == 1);
+}
+
+fun testFreshEnvironment() {
+  count = count + 1;
+  assertEqual(1, count);
+}
+
+fun testEqualFails() {
+  assertEqual("3", add(1, 2));
+}
+
+fun testTrueFails() {
+  assertTrue(1);
+}
+
+fun testThrows() {
+  assertEqual("Operand must be a number.", assertThrows(fun_negate_string));
+}
+
+fun testThrowsFails() {
+  assertThrows(fun_negate_number);
+}
+
+fun testRuntimeError() {
+  add(nil, 1);
+}
+
+fun fun_negate_string() { negate("a"); }
+fun fun_negate_number() { negate(1); }
+fun testWithParam(x) {}
+`
+
+func run(t *testing.T, src string) *Suite {
+	statements, err := parser.ParseStmts(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	interpreter.SetOutput(&bytes.Buffer{})
+	defer interpreter.SetOutput(nil)
+	return Run("math_test.lox", statements)
+}
+
+func TestRun(t *testing.T) {
+	suite := run(t, source)
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{"testAdd", ""},
+		{"testFreshEnvironment", ""},
+		{"testEqualFails", `math_test.lox:17:3: assertEqual: expected "3", got 3`},
+		{"testTrueFails", "math_test.lox:21:3: assertTrue: got 1"},
+		{"testThrows", ""},
+		{"testThrowsFails", "math_test.lox:29:3: assertThrows: no error"},
+		{"testRuntimeError", "math_test.lox:2:17: Operands must be numbers or strings."},
+	}
+	if len(suite.Tests) != len(tests) {
+		t.Fatalf("wrong number of tests. expected=%d, got=%d", len(tests), len(suite.Tests))
+	}
+	for i, tt := range tests {
+		r := suite.Tests[i]
+		if r.Name != tt.name {
+			t.Errorf("tests[%d] - name wrong. expected=%s, got=%s", i, tt.name, r.Name)
+		}
+		err := ""
+		if r.Err != nil {
+			err = suite.Message(r.Err)
+		}
+		if err != tt.err {
+			t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, tt.err, err)
+		}
+	}
+	if !suite.Failed() || suite.Failures() != 4 {
+		t.Errorf("failures wrong. expected=4, got=%d", suite.Failures())
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	suite := run(t, "assertEqual(1, 1);\nassertEqual(1, 2);\n")
+	if len(suite.Tests) != 0 {
+		t.Fatalf("script has tests. got=%d", len(suite.Tests))
+	}
+	if suite.Err == nil || suite.Message(suite.Err) != "math_test.lox:2:1: assertEqual: expected 1, got 2" {
+		t.Errorf("error wrong. got=%v", suite.Err)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	suite := run(t, source)
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, []*Suite{suite}); err != nil {
+		t.Fatal(err)
+	}
+	xml := buf.String()
+	for _, s := range []string{
+		`<testsuite name="math_test.lox" tests="7" failures="4" errors="0"`,
+		`<testcase name="testAdd" classname="math_test.lox"`,
+		`<failure message="math_test.lox:21:3: assertTrue: got 1">`,
+	} {
+		if !strings.Contains(xml, s) {
+			t.Errorf("report doesn't contain %q. got=\n%s", s, xml)
+		}
+	}
+}
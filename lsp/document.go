@@ -0,0 +1,338 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/lexer"
+	"github.com/ziyoung/lox-go/lint"
+	"github.com/ziyoung/lox-go/parser"
+	"github.com/ziyoung/lox-go/resolver"
+	"github.com/ziyoung/lox-go/token"
+)
+
+// positionError is implemented by parser and resolver errors.
+type positionError interface {
+	Pos() token.Position
+	Message() string
+}
+
+// document is an open text document along with its analysis.
+type document struct {
+	uri  string
+	text string
+	// lines are offsets of line starts.
+	lines []int
+
+	statements []ast.Stmt
+	// info is nil if the document has errors.
+	info        *resolver.Info
+	diagnostics []Diagnostic
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, text: text, lines: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.analyze()
+	return d
+}
+
+// analyze parses and resolves the document and collects diagnostics.
+func (d *document) analyze() {
+	d.diagnostics = []Diagnostic{}
+	p := parser.New(lexer.New(d.text))
+	statements, err := p.Parse()
+	if err != nil {
+		d.addError(err)
+		return
+	}
+	d.statements = statements
+	info, err := resolver.Analyze(statements)
+	if err != nil {
+		d.addError(err)
+		return
+	}
+	d.info = info
+	warnings, _ := lint.Check(statements, p.Comments())
+	for _, w := range warnings {
+		d.diagnostics = append(d.diagnostics, Diagnostic{
+			Range:    d.wordRange(w.Pos),
+			Severity: SeverityWarning,
+			Code:     w.Code,
+			Source:   "lox",
+			Message:  w.Message,
+		})
+	}
+}
+
+func (d *document) addError(err error) {
+	diag := Diagnostic{Severity: SeverityError, Source: "lox", Message: err.Error()}
+	if e, ok := err.(positionError); ok {
+		diag.Message = e.Message()
+		if e.Pos().IsValid() {
+			diag.Range = d.wordRange(e.Pos())
+		}
+	}
+	d.diagnostics = append(d.diagnostics, diag)
+}
+
+// position converts a byte offset to a LSP position.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset }) - 1
+	return Position{Line: line, Character: utf16Len(d.text[d.lines[line]:offset])}
+}
+
+// offset converts a LSP position to a byte offset.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	start := d.lines[pos.Line]
+	n := 0
+	for i, r := range d.text[start:] {
+		if n >= pos.Character || r == '\n' {
+			return start + i
+		}
+		n += utf16RuneLen(r)
+	}
+	return len(d.text)
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// nameRange returns range of name starting at pos.
+func (d *document) nameRange(pos token.Position, name string) Range {
+	return d.rangeOf(pos.Offset, pos.Offset+len(name))
+}
+
+// wordRange returns range of the identifier at pos, or of a single character.
+func (d *document) wordRange(pos token.Position) Range {
+	end := pos.Offset
+	for end < len(d.text) && isIdentByte(d.text[end]) {
+		end++
+	}
+	if end == pos.Offset && end < len(d.text) {
+		_, size := utf8.DecodeRuneInString(d.text[end:])
+		end += size
+	}
+	return d.rangeOf(pos.Offset, end)
+}
+
+// stmtRange returns range of stmt, which ends with a single character token.
+func (d *document) stmtRange(stmt ast.Stmt) Range {
+	return d.rangeOf(stmt.Pos().Offset, stmt.End().Offset+1)
+}
+
+// bindingAt returns the binding whose name is at offset, along with the start of the name.
+func (d *document) bindingAt(offset int) (*resolver.Binding, int) {
+	if d.info == nil {
+		return nil, 0
+	}
+	contains := func(pos token.Position, name string) bool {
+		return pos.Offset <= offset && offset <= pos.Offset+len(name)
+	}
+	for _, b := range d.info.Bindings {
+		if contains(b.Pos, b.Name) {
+			return b, b.Pos.Offset
+		}
+		for _, expr := range b.Reads {
+			if contains(expr.NamePos, b.Name) {
+				return b, expr.NamePos.Offset
+			}
+		}
+		for _, expr := range b.Writes {
+			if contains(expr.Left.NamePos, b.Name) {
+				return b, expr.Left.NamePos.Offset
+			}
+		}
+	}
+	return nil, 0
+}
+
+// references returns ranges of all occurrences of b.
+func (d *document) references(b *resolver.Binding, includeDeclaration bool) []Range {
+	var ranges []Range
+	if includeDeclaration {
+		ranges = append(ranges, d.nameRange(b.Pos, b.Name))
+	}
+	for _, expr := range b.Reads {
+		ranges = append(ranges, d.nameRange(expr.NamePos, b.Name))
+	}
+	for _, expr := range b.Writes {
+		ranges = append(ranges, d.nameRange(expr.Left.NamePos, b.Name))
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		a, b := ranges[i].Start, ranges[j].Start
+		return a.Line < b.Line || a.Line == b.Line && a.Character < b.Character
+	})
+	return ranges
+}
+
+// describe returns hover text of b.
+func (d *document) describe(b *resolver.Binding) string {
+	switch decl := b.Decl.(type) {
+	case *ast.FunctionStmt:
+		return fmt.Sprintf("fun %s(%s)", decl.Name, params(decl))
+	case *ast.ClassStmt:
+		s := "class " + decl.Name
+		if decl.SuperClass.Name != "" {
+			s += " < " + decl.SuperClass.Name
+		}
+		return s
+	case *ast.ImportStmt:
+		if len(decl.Names) == 0 {
+			return fmt.Sprintf("import %q as %s", decl.Path, b.Name)
+		}
+		return fmt.Sprintf("from %q import %s", decl.Path, b.Name)
+	case *ast.VarStmt:
+		s := "var " + b.Name
+		if kind := d.kindOf(decl.Initializer); kind != "" {
+			s += ": " + kind
+		}
+		return s
+	}
+	return b.Kind.String() + " " + b.Name
+}
+
+// kindOf returns kind of the value of expr if it can be told statically.
+func (d *document) kindOf(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case nil:
+		return "nil"
+	case *ast.Literal:
+		switch e.Token {
+		case token.Number:
+			return "number"
+		case token.String:
+			return "string"
+		case token.True, token.False:
+			return "boolean"
+		case token.Nil:
+			return "nil"
+		}
+	case *ast.GroupingExpr:
+		return d.kindOf(e.Expression)
+	case *ast.InterpolationExpr:
+		return "string"
+	case *ast.UnaryExpr:
+		if e.Operator == token.Bang {
+			return "boolean"
+		}
+		return "number"
+	case *ast.BinaryExpr:
+		switch e.Operator {
+		case token.Minus, token.Star, token.Slash:
+			return "number"
+		case token.Plus:
+			left, right := d.kindOf(e.Left), d.kindOf(e.Right)
+			if left == "string" || right == "string" {
+				return "string"
+			}
+			if left == "number" && right == "number" {
+				return "number"
+			}
+		default:
+			return "boolean"
+		}
+	case *ast.VariableExpr:
+		if b := d.info.Uses[e]; b != nil && len(b.Writes) == 0 {
+			switch b.Kind {
+			case resolver.FunctionBinding, resolver.ClassBinding:
+				return b.Kind.String()
+			}
+		}
+	case *ast.CallExpr:
+		if callee, ok := e.Callee.(*ast.VariableExpr); ok {
+			if b := d.info.Uses[callee]; b != nil && b.Kind == resolver.ClassBinding && len(b.Writes) == 0 {
+				return b.Name + " instance"
+			}
+		}
+	}
+	return ""
+}
+
+func params(fn *ast.FunctionStmt) string {
+	names := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		names[i] = param.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// symbols returns symbols of top-level declarations.
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range d.statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionStmt:
+			symbols = append(symbols, d.functionSymbol(s, SymbolFunction))
+		case *ast.ClassStmt:
+			sym := DocumentSymbol{
+				Name:           s.Name,
+				Kind:           SymbolClass,
+				Range:          d.stmtRange(s),
+				SelectionRange: d.nameRange(s.NamePos, s.Name),
+			}
+			if s.SuperClass.Name != "" {
+				sym.Detail = "< " + s.SuperClass.Name
+			}
+			for _, method := range s.Methods {
+				sym.Children = append(sym.Children, d.functionSymbol(method, SymbolMethod))
+			}
+			symbols = append(symbols, sym)
+		case *ast.VarStmt:
+			symbols = append(symbols, DocumentSymbol{
+				Name:           s.Name.Name,
+				Kind:           SymbolVariable,
+				Range:          d.stmtRange(s),
+				SelectionRange: d.nameRange(s.Name.NamePos, s.Name.Name),
+			})
+		}
+	}
+	return symbols
+}
+
+func (d *document) functionSymbol(fn *ast.FunctionStmt, kind int) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           fn.Name,
+		Detail:         "(" + params(fn) + ")",
+		Kind:           kind,
+		Range:          d.stmtRange(fn),
+		SelectionRange: d.nameRange(fn.NamePos, fn.Name),
+	}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+// utf16RuneLen returns the number of UTF-16 code units encoding r.
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b >= utf8.RuneSelf
+}
@@ -0,0 +1,271 @@
+// Package lsp implements a Language Server Protocol server for Lox.
+//
+// The server talks JSON-RPC 2.0 framed with Content-Length headers, usually over stdio.
+// Documents are synced by full content and analyzed on every change.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/ziyoung/lox-go/format"
+)
+
+// Server is a language server reading requests from in and writing responses to out.
+type Server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	docs map[string]*document
+
+	shutdown bool
+}
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                  (*Server).initialize,
+		"shutdown":                    (*Server).handleShutdown,
+		"textDocument/didOpen":        (*Server).didOpen,
+		"textDocument/didChange":      (*Server).didChange,
+		"textDocument/didClose":       (*Server).didClose,
+		"textDocument/definition":     (*Server).definition,
+		"textDocument/references":     (*Server).references,
+		"textDocument/hover":          (*Server).hover,
+		"textDocument/documentSymbol": (*Server).documentSymbol,
+		"textDocument/formatting":     (*Server).formatting,
+	}
+}
+
+// NewServer returns a server reading from in and writing to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the exit notification or the end of input.
+func (s *Server) Serve() error {
+	for {
+		b, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			if err := s.replyError(nil, &responseError{codeParseError, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches req. Only errors in writing the response are returned.
+func (s *Server) handle(req *request) error {
+	h, ok := handlers[req.Method]
+	if req.ID == nil {
+		// notifications don't have responses.
+		if ok && !s.shutdown {
+			h(s, req.Params)
+		}
+		return nil
+	}
+	if !ok {
+		return s.replyError(req.ID, &responseError{codeMethodNotFound, "method not found: " + req.Method})
+	}
+	if s.shutdown {
+		return s.replyError(req.ID, &responseError{codeInvalidRequest, "server is shut down"})
+	}
+	result, err := h(s, req.Params)
+	if err != nil {
+		respErr, ok := err.(*responseError)
+		if !ok {
+			respErr = &responseError{codeInvalidParams, err.Error()}
+		}
+		return s.replyError(req.ID, respErr)
+	}
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) replyError(id *json.RawMessage, err *responseError) error {
+	return writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var result InitializeResult
+	result.Capabilities = ServerCapabilities{
+		TextDocumentSync:           syncFull,
+		DefinitionProvider:         true,
+		ReferencesProvider:         true,
+		HoverProvider:              true,
+		DocumentSymbolProvider:     true,
+		DocumentFormattingProvider: true,
+	}
+	result.ServerInfo.Name = "lox"
+	return result, nil
+}
+
+func (s *Server) handleShutdown(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+	return nil, s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.docs, p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// update analyzes the new content of a document and publishes its diagnostics.
+func (s *Server) update(uri, text string) error {
+	d := newDocument(uri, text)
+	s.docs[uri] = d
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: d.diagnostics,
+	})
+}
+
+// document returns the open document of a request.
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{codeInvalidParams, "document is not open: " + uri}
+	}
+	return d, nil
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := d.bindingAt(d.offset(p.Position))
+	if b == nil {
+		return nil, nil
+	}
+	return Location{URI: d.uri, Range: d.nameRange(b.Pos, b.Name)}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := d.bindingAt(d.offset(p.Position))
+	if b == nil {
+		return nil, nil
+	}
+	locations := []Location{}
+	for _, r := range d.references(b, p.Context.IncludeDeclaration) {
+		locations = append(locations, Location{URI: d.uri, Range: r})
+	}
+	return locations, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	b, start := d.bindingAt(d.offset(p.Position))
+	if b == nil {
+		return nil, nil
+	}
+	return Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: "```lox\n" + d.describe(b) + "\n```\n" + b.Kind.String() + " declared at " + b.Pos.String(),
+		},
+		Range: d.rangeOf(start, start+len(b.Name)),
+	}, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.symbols(), nil
+}
+
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentFormattingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	b, err := format.Source([]byte(d.text))
+	if err != nil {
+		// documents with syntax errors are left unchanged.
+		return nil, nil
+	}
+	edits := []TextEdit{}
+	if string(b) != d.text {
+		edits = append(edits, TextEdit{Range: d.rangeOf(0, len(d.text)), NewText: string(b)})
+	}
+	return edits, nil
+}
@@ -0,0 +1,319 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+const testURI = "file:///test.lox"
+
+type testMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// serve runs a server with requests and returns all messages it writes.
+// Requests are given IDs from 1 in order, unless method is a notification.
+func serve(t *testing.T, requests ...interface{}) []testMessage {
+	var in bytes.Buffer
+	id := 0
+	for i := 0; i < len(requests); i += 2 {
+		method := requests[i].(string)
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": requests[i+1]}
+		if !isNotification(method) {
+			id++
+			msg["id"] = id
+		}
+		if err := writeMessage(&in, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Serve(); err != nil {
+		t.Fatalf("serve failed. error: %s", err.Error())
+	}
+	var messages []testMessage
+	r := bufio.NewReader(&out)
+	for {
+		b, err := readMessage(r)
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("invalid output. error: %s", err.Error())
+		}
+		var msg testMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func isNotification(method string) bool {
+	switch method {
+	case "initialized", "exit", "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+		return true
+	}
+	return false
+}
+
+// result returns result of the response to request id.
+func result(t *testing.T, messages []testMessage, id int, v interface{}) {
+	for _, msg := range messages {
+		if msg.ID != nil && *msg.ID == id {
+			if msg.Error != nil {
+				t.Fatalf("request %d failed. error: %s", id, msg.Error.Message)
+			}
+			if err := json.Unmarshal(msg.Result, v); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("no response to request %d", id)
+}
+
+func open(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI, "languageId": "lox", "version": 1, "text": text},
+	}
+}
+
+func at(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+		"position":     map[string]interface{}{"line": line, "character": character},
+		"context":      map[string]interface{}{"includeDeclaration": true},
+	}
+}
+
+var testDocument = map[string]interface{}{"textDocument": map[string]interface{}{"uri": testURI}}
+
+func rangeString(r Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
+
+func TestInitialize(t *testing.T) {
+	messages := serve(t,
+		"initialize", map[string]interface{}{},
+		"initialized", map[string]interface{}{},
+		"unknown", nil,
+		"shutdown", nil,
+		"textDocument/hover", at(0, 0),
+		"exit", nil,
+	)
+	var init InitializeResult
+	result(t, messages, 1, &init)
+	caps := init.Capabilities
+	if caps.TextDocumentSync != syncFull || !caps.DefinitionProvider || !caps.HoverProvider || !caps.DocumentFormattingProvider {
+		t.Errorf("unexpected capabilities %+v", caps)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 responses. got %d", len(messages))
+	}
+	if messages[1].Error == nil || messages[1].Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found error. got %+v", messages[1].Error)
+	}
+	if messages[3].Error == nil || messages[3].Error.Code != codeInvalidRequest {
+		t.Errorf("expected invalid request error after shutdown. got %+v", messages[3].Error)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"var a = 1;\nprint a;", nil},
+		{"var a = 1;\nprint a +;", []string{"1:9-1:10 error Expect expression."}},
+		{"fun f() {}\nreturn 1;", []string{"1:0-1:6 error Cannot return from top-level code."}},
+		{"{\n  var a = 1;\n}", []string{"1:6-1:7 warning L001 variable a is never used"}},
+	}
+
+	for i, test := range tests {
+		messages := serve(t, "textDocument/didOpen", open(test.text))
+		if len(messages) != 1 || messages[0].Method != "textDocument/publishDiagnostics" {
+			t.Fatalf("test [%d]: expected diagnostics. got %+v", i, messages)
+		}
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(messages[0].Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		if params.URI != testURI || len(params.Diagnostics) != len(test.expected) {
+			t.Fatalf("test [%d]: expected %d diagnostics. got %+v", i, len(test.expected), params)
+		}
+		for j, diag := range params.Diagnostics {
+			severity := "error"
+			if diag.Severity == SeverityWarning {
+				severity = "warning " + diag.Code
+			}
+			got := fmt.Sprintf("%s %s %s", rangeString(diag.Range), severity, diag.Message)
+			if got != test.expected[j] {
+				t.Errorf("test [%d]: expected diagnostic %q. got %q", i, test.expected[j], got)
+			}
+		}
+	}
+}
+
+func TestDidChange(t *testing.T) {
+	change := map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": testURI, "version": 2},
+		"contentChanges": []interface{}{map[string]interface{}{"text": "print 1;"}},
+	}
+	messages := serve(t,
+		"textDocument/didOpen", open("print ;"),
+		"textDocument/didChange", change,
+		"textDocument/didClose", testDocument,
+	)
+	counts := make([]int, len(messages))
+	for i, msg := range messages {
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+		counts[i] = len(params.Diagnostics)
+	}
+	if fmt.Sprint(counts) != "[1 0 0]" {
+		t.Errorf("expected diagnostic counts [1 0 0]. got %v", counts)
+	}
+}
+
+const source = `var a = 1;
+fun add(x, y) {
+    return x + y;
+}
+class Point {
+    init(x) {
+        this.x = x;
+    }
+}
+a = add(a, 2);
+var p = Point(a);
+var s = "é" + "";
+print s;
+`
+
+func TestDefinitionAndReferences(t *testing.T) {
+	messages := serve(t,
+		"textDocument/didOpen", open(source),
+		"textDocument/definition", at(9, 9), // a in add(a, 2)
+		"textDocument/references", at(0, 4), // declaration of a
+		"textDocument/definition", at(2, 15), // y in x + y
+		"textDocument/definition", at(6, 14), // property x
+		"textDocument/references", at(12, 7), // s in print s
+	)
+
+	var loc Location
+	result(t, messages, 1, &loc)
+	if loc.URI != testURI || rangeString(loc.Range) != "0:4-0:5" {
+		t.Errorf("expected definition at 0:4-0:5. got %+v", loc)
+	}
+
+	var locs []Location
+	result(t, messages, 2, &locs)
+	var got []string
+	for _, l := range locs {
+		got = append(got, rangeString(l.Range))
+	}
+	if fmt.Sprint(got) != "[0:4-0:5 9:0-9:1 9:8-9:9 10:14-10:15]" {
+		t.Errorf("unexpected references %v", got)
+	}
+
+	result(t, messages, 3, &loc)
+	if rangeString(loc.Range) != "1:11-1:12" {
+		t.Errorf("expected definition at 1:11-1:12. got %+v", loc)
+	}
+
+	var none *Location
+	result(t, messages, 4, &none)
+	if none != nil {
+		t.Errorf("expected no definition. got %+v", none)
+	}
+
+	result(t, messages, 5, &locs)
+	got = nil
+	for _, l := range locs {
+		got = append(got, rangeString(l.Range))
+	}
+	if fmt.Sprint(got) != "[11:4-11:5 12:6-12:7]" {
+		t.Errorf("unexpected references %v", got)
+	}
+}
+
+func TestHover(t *testing.T) {
+	tests := []struct {
+		line, character int
+		expected        string
+	}{
+		{9, 4, "```lox\nfun add(x, y)\n```\nfunction declared at 2:5"},
+		{0, 4, "```lox\nvar a: number\n```\nvariable declared at 1:5"},
+		{10, 5, "```lox\nvar p: Point instance\n```\nvariable declared at 11:5"},
+		{11, 4, "```lox\nvar s: string\n```\nvariable declared at 12:5"},
+		{6, 18, "```lox\nparameter x\n```\nparameter declared at 6:10"},
+		{4, 8, "```lox\nclass Point\n```\nclass declared at 5:7"},
+	}
+
+	requests := []interface{}{"textDocument/didOpen", open(source)}
+	for _, test := range tests {
+		requests = append(requests, "textDocument/hover", at(test.line, test.character))
+	}
+	messages := serve(t, requests...)
+	for i, test := range tests {
+		var hover Hover
+		result(t, messages, i+1, &hover)
+		if hover.Contents.Value != test.expected {
+			t.Errorf("test [%d]: expected hover %q. got %q", i, test.expected, hover.Contents.Value)
+		}
+	}
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	messages := serve(t,
+		"textDocument/didOpen", open(source),
+		"textDocument/documentSymbol", testDocument,
+	)
+	var symbols []DocumentSymbol
+	result(t, messages, 1, &symbols)
+	var got []string
+	for _, sym := range symbols {
+		got = append(got, fmt.Sprintf("%s %d %s %s", sym.Name, sym.Kind, rangeString(sym.Range), rangeString(sym.SelectionRange)))
+		for _, child := range sym.Children {
+			got = append(got, fmt.Sprintf("  %s%s %d", child.Name, child.Detail, child.Kind))
+		}
+	}
+	expected := []string{
+		"a 13 0:0-0:10 0:4-0:5",
+		"add 12 1:0-3:1 1:4-1:7",
+		"Point 5 4:0-8:1 4:6-4:11",
+		"  init(x) 6",
+		"p 13 10:0-10:17 10:4-10:5",
+		"s 13 11:0-11:17 11:4-11:5",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected symbols\n%v\ngot\n%v", expected, got)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	messages := serve(t,
+		"textDocument/didOpen", open("var a=1;\nprint a;"),
+		"textDocument/formatting", testDocument,
+	)
+	var edits []TextEdit
+	result(t, messages, 1, &edits)
+	if len(edits) != 1 {
+		t.Fatalf("expected 1 edit. got %+v", edits)
+	}
+	if rangeString(edits[0].Range) != "0:0-1:8" || edits[0].NewText != "var a = 1;\nprint a;\n" {
+		t.Errorf("unexpected edit %+v", edits[0])
+	}
+}
@@ -0,0 +1,164 @@
+// Package mod reads lox.mod manifests and resolves imports of packages vendored
+// into lox_modules.
+//
+// A manifest names the package and the packages it requires by local paths,
+// which are relative to the directory of the manifest:
+//
+//	// lox.mod
+//	package app
+//
+//	require geometry ../geometry
+//	require strings ./third_party/strings
+//
+// "lox vendor" copies required packages, including what they require, into the
+// lox_modules directory next to the manifest and records their checksums in lox.sum.
+// An import path whose first element is a vendored package, such as "geometry/shapes",
+// is resolved to lox_modules/geometry/shapes.lox.
+package mod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ManifestFile is the name of manifests.
+	ManifestFile = "lox.mod"
+	// LockFile is the name of the file recording checksums of vendored packages.
+	LockFile = "lox.sum"
+	// ModulesDir is the name of the directory where packages are vendored.
+	ModulesDir = "lox_modules"
+)
+
+// Manifest is a parsed lox.mod file.
+type Manifest struct {
+	// Dir is the absolute name of the directory of the manifest.
+	Dir      string
+	Package  string
+	Requires []Require
+}
+
+// Require is a package required by a manifest.
+type Require struct {
+	Name string
+	// Path is the directory of the package, relative to the manifest.
+	Path string
+}
+
+// Parse parses the manifest file whose content is data.
+func Parse(file string, data []byte) (*Manifest, error) {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Dir: dir}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if i := strings.Index(text, "//"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		errorf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%s:%d: %s", file, line, fmt.Sprintf(format, args...))
+		}
+		switch fields[0] {
+		case "package":
+			if len(fields) != 2 || !validName(fields[1]) {
+				return nil, errorf("usage: package name")
+			}
+			if m.Package != "" {
+				return nil, errorf("repeated package directive")
+			}
+			m.Package = fields[1]
+		case "require":
+			if len(fields) != 3 || !validName(fields[1]) {
+				return nil, errorf("usage: require name path")
+			}
+			if _, ok := m.Require(fields[1]); ok {
+				return nil, errorf("%s is required more than once", fields[1])
+			}
+			m.Requires = append(m.Requires, Require{Name: fields[1], Path: fields[2]})
+		default:
+			return nil, errorf("unknown directive %s", fields[0])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if m.Package == "" {
+		return nil, fmt.Errorf("%s: missing package directive", file)
+	}
+	return m, nil
+}
+
+// validName reports whether name can be a package name, which is the first element
+// of import paths.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// Load reads the manifest in dir.
+func Load(dir string) (*Manifest, error) {
+	file := filepath.Join(dir, ManifestFile)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(file, data)
+}
+
+// Find reads the manifest in dir or the nearest parent directory of it. It returns
+// nil if there's no manifest.
+func Find(dir string) (*Manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		m, err := Load(dir)
+		if !os.IsNotExist(err) {
+			return m, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Require returns the package named name required by m.
+func (m *Manifest) Require(name string) (Require, bool) {
+	for _, r := range m.Requires {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Require{}, false
+}
+
+// Resolve returns the vendored file imported as path. The first element of path is
+// the name of a package required by m, directly or by another package.
+func (m *Manifest) Resolve(path string) (string, bool) {
+	path = filepath.ToSlash(path)
+	if name := strings.SplitN(path, "/", 2)[0]; !validName(name) {
+		return "", false
+	}
+	if filepath.Ext(path) != ".lox" {
+		path += ".lox"
+	}
+	file := filepath.Join(m.Dir, ModulesDir, filepath.FromSlash(path))
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		return "", false
+	}
+	return file, true
+}
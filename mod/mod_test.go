@@ -0,0 +1,138 @@
+package mod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"package app\n", "app", ""},
+		{"// app\npackage app // name\n\nrequire geometry ../geometry\nrequire strings ./lib/strings\n",
+			"app geometry=../geometry strings=./lib/strings", ""},
+		{"require geometry ../geometry\n", "", "lox.mod: missing package directive"},
+		{"package app\npackage b\n", "", "lox.mod:2: repeated package directive"},
+		{"package app\nrequire geometry\n", "", "lox.mod:2: usage: require name path"},
+		{"package app\nrequire a/b ../b\n", "", "lox.mod:2: usage: require name path"},
+		{"package app\nrequire a ../a\nrequire a ../b\n", "", "lox.mod:3: a is required more than once"},
+		{"package app\nreplace a ../a\n", "", "lox.mod:2: unknown directive replace"},
+	}
+	for i, tt := range tests {
+		m, err := Parse(ManifestFile, []byte(tt.input))
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("test [%d]: expected error %q. got %v", i, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err)
+		}
+		got := []string{m.Package}
+		for _, r := range m.Requires {
+			got = append(got, r.Name+"="+r.Path)
+		}
+		if s := strings.Join(got, " "); s != tt.expected {
+			t.Errorf("test [%d]: expected %q. got %q", i, tt.expected, s)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"app/lox.mod":                 "package app\nrequire geometry ../geometry\n",
+		"app/src/main.lox":            `import "geometry/shapes" as shapes;`,
+		"geometry/lox.mod":            "package geometry\nrequire util ../util\n",
+		"geometry/shapes.lox":         `from "util/math" import square;`,
+		"geometry/shapes_test.lox":    "fun testArea() {}",
+		"geometry/internal/area.lox":  "",
+		"geometry/lox_modules/util/x": "",
+		"geometry/.git/config":        "",
+		"util/math.lox":               "fun square(x) { return x * x; }",
+		"util/README.md":              "",
+	})
+
+	m, err := Find(filepath.Join(dir, "app", "src"))
+	if err != nil || m == nil {
+		t.Fatalf("manifest not found. error: %v", err)
+	}
+	packages, err := Vendor(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range packages {
+		got = append(got, p.Name+":"+strings.Join(p.Files, ","))
+	}
+	expected := "geometry:internal/area.lox,lox.mod,shapes.lox util:math.lox"
+	if s := strings.Join(got, " "); s != expected {
+		t.Errorf("packages wrong. expected=%q, got=%q", expected, s)
+	}
+
+	modules := filepath.Join(dir, "app", ModulesDir)
+	for _, tt := range []struct {
+		path string
+		file string
+	}{
+		{"geometry/shapes", filepath.Join(modules, "geometry", "shapes.lox")},
+		{"geometry/internal/area.lox", filepath.Join(modules, "geometry", "internal", "area.lox")},
+		{"util/math", filepath.Join(modules, "util", "math.lox")},
+		{"geometry/shapes_test", ""},
+		{"other/shapes", ""},
+	} {
+		file, ok := m.Resolve(tt.path)
+		if file != tt.file || ok != (tt.file != "") {
+			t.Errorf("Resolve(%q) wrong. expected=%q, got=%q", tt.path, tt.file, file)
+		}
+	}
+
+	sums, err := ReadLock(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 2 || sums["geometry"] != packages[0].Sum || !strings.HasPrefix(sums["util"], "h1:") {
+		t.Errorf("lock wrong. got %v", sums)
+	}
+	if err := Verify(m); err != nil {
+		t.Errorf("verify failed. error: %s", err)
+	}
+	writeFiles(t, modules, map[string]string{"util/math.lox": "fun square(x) { return x; }"})
+	if err := Verify(m); err == nil || !strings.HasPrefix(err.Error(), "package util: checksum mismatch") {
+		t.Errorf("expected checksum mismatch. got %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"app/lox.mod": "package app\nrequire geometry ../geometry\nrequire util ../other\n",
+		"other/x.lox": "",
+	})
+	if m, err = Load(filepath.Join(dir, "app")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Vendor(m); err == nil || !strings.Contains(err.Error(), "util is required from both") {
+		t.Errorf("expected conflicting requirements. got %v", err)
+	}
+}
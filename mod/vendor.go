@@ -0,0 +1,228 @@
+package mod
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package is a package to be vendored.
+type Package struct {
+	Name string
+	// Dir is the absolute name of the directory of the package.
+	Dir string
+	// Files are names of the files of the package relative to Dir, with slashes.
+	Files []string
+	Sum   string
+}
+
+// Packages returns the packages required by m, directly or by other packages,
+// sorted by name. Packages of the same name must be in the same directory.
+func Packages(m *Manifest) ([]*Package, error) {
+	found := make(map[string]*Package)
+	var visit func(m *Manifest) error
+	visit = func(m *Manifest) error {
+		for _, r := range m.Requires {
+			dir := filepath.Join(m.Dir, filepath.FromSlash(r.Path))
+			if p, ok := found[r.Name]; ok {
+				if p.Dir != dir {
+					return fmt.Errorf("%s is required from both %s and %s", r.Name, p.Dir, dir)
+				}
+				continue
+			}
+			p := &Package{Name: r.Name, Dir: dir}
+			found[r.Name] = p
+			dep, err := Load(dir)
+			switch {
+			case os.IsNotExist(err):
+				// a directory of Lox files without a manifest requires nothing.
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					return fmt.Errorf("package %s: %s is not a directory", r.Name, dir)
+				}
+			case err != nil:
+				return err
+			default:
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(m); err != nil {
+		return nil, err
+	}
+
+	packages := make([]*Package, 0, len(found))
+	for _, p := range found {
+		files, err := packageFiles(p.Dir)
+		if err != nil {
+			return nil, err
+		}
+		p.Files = files
+		if p.Sum, err = hashFiles(p.Dir, files); err != nil {
+			return nil, err
+		}
+		packages = append(packages, p)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages, nil
+}
+
+// packageFiles returns the files of the package in dir which are vendored: the
+// manifest and Lox files except tests. Hidden directories and lox_modules are skipped.
+func packageFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if file != dir && (name == ModulesDir || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == ManifestFile && filepath.Dir(file) == dir ||
+			filepath.Ext(name) == ".lox" && !strings.HasSuffix(name, "_test.lox") {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// hashFiles returns the checksum of files in dir. It is the SHA-256 of the list of
+// files and the SHA-256 of their contents, prefixed with "h1:" like Go modules.
+func hashFiles(dir string, files []string) (string, error) {
+	h := sha256.New()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%x  %s\n", sha256.Sum256(data), file)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// Vendor replaces the lox_modules directory of m with copies of the packages it
+// requires, and writes their checksums to lox.sum. It returns the vendored packages.
+func Vendor(m *Manifest) ([]*Package, error) {
+	packages, err := Packages(m)
+	if err != nil {
+		return nil, err
+	}
+	modules := filepath.Join(m.Dir, ModulesDir)
+	if err := os.RemoveAll(modules); err != nil {
+		return nil, err
+	}
+	for _, p := range packages {
+		for _, file := range p.Files {
+			data, err := ioutil.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, err
+			}
+			dst := filepath.Join(modules, p.Name, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(dst, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, p := range packages {
+		fmt.Fprintf(&buf, "%s %s\n", p.Name, p.Sum)
+	}
+	if err := ioutil.WriteFile(filepath.Join(m.Dir, LockFile), buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
+// ReadLock reads lox.sum of m, which maps package names to checksums.
+func ReadLock(m *Manifest) (map[string]string, error) {
+	file := filepath.Join(m.Dir, LockFile)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed line", file, line)
+		}
+		sums[fields[0]] = fields[1]
+	}
+	return sums, s.Err()
+}
+
+// Verify checks that the packages in lox_modules are those recorded in lox.sum,
+// and that every package required by m is vendored.
+func Verify(m *Manifest) error {
+	sums, err := ReadLock(m)
+	if err != nil {
+		return err
+	}
+	modules := filepath.Join(m.Dir, ModulesDir)
+	infos, err := ioutil.ReadDir(modules)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	vendored := make(map[string]bool)
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		vendored[name] = true
+		sum, ok := sums[name]
+		if !ok {
+			return fmt.Errorf("%s: package %s is not in %s", modules, name, LockFile)
+		}
+		dir := filepath.Join(modules, name)
+		files, err := packageFiles(dir)
+		if err != nil {
+			return err
+		}
+		got, err := hashFiles(dir, files)
+		if err != nil {
+			return err
+		}
+		if got != sum {
+			return fmt.Errorf("package %s: checksum mismatch\n\t%s: %s\n\t%s: %s", name, LockFile, sum, ModulesDir, got)
+		}
+	}
+	for name := range sums {
+		if !vendored[name] {
+			return fmt.Errorf("package %s is in %s but not vendored", name, LockFile)
+		}
+	}
+	for _, r := range m.Requires {
+		if !vendored[r.Name] {
+			return fmt.Errorf("package %s is required but not vendored", r.Name)
+		}
+	}
+	return nil
+}
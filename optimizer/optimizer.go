@@ -0,0 +1,230 @@
+// Package optimizer simplifies resolved programs before they are executed.
+//
+// Operations whose operands are literals, such as 60 * 60 * 24 or "a" + 1, are
+// folded into literals, logical expressions with a literal left operand are
+// replaced by the operand they evaluate to, and if and while statements with
+// literal conditions are replaced by the branch which runs. Statements following
+// a return statement in the same block are removed.
+//
+// Operations which fail at run time, such as 1 / 0 or -"a", are kept so that the
+// error is reported when and where it would be without optimization.
+package optimizer
+
+import (
+	"strconv"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/token"
+	"github.com/ziyoung/lox-go/valuer"
+)
+
+// Optimize optimizes statements in place and returns the optimized statements.
+// Variables of statements must be resolved, since optimization may remove scopes.
+func Optimize(statements []ast.Stmt) []ast.Stmt {
+	block := &ast.BlockStmt{Statements: statements}
+	ast.Rewrite(block, optimize)
+	return block.Statements
+}
+
+func optimize(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.GroupingExpr:
+		if lit, ok := n.Expression.(*ast.Literal); ok {
+			return lit
+		}
+	case *ast.UnaryExpr:
+		return foldUnary(n)
+	case *ast.BinaryExpr:
+		return foldBinary(n)
+	case *ast.InterpolationExpr:
+		return foldInterpolation(n)
+	case *ast.LogicalExpr:
+		v, ok := constant(n.Left)
+		if !ok || n.Operator != token.And && n.Operator != token.Or {
+			break
+		}
+		// or evaluates to a truthy left operand, and to a falsy one.
+		if valuer.IsTruthy(v) == (n.Operator == token.Or) {
+			return n.Left
+		}
+		return n.Right
+	case *ast.IfStmt:
+		v, ok := constant(n.Condition)
+		if !ok {
+			break
+		}
+		if valuer.IsTruthy(v) {
+			return n.ThenBranch
+		}
+		if n.ElseBranch != nil {
+			return n.ElseBranch
+		}
+		return empty(n)
+	case *ast.WhileStmt:
+		if v, ok := constant(n.Condition); ok && !valuer.IsTruthy(v) {
+			return empty(n)
+		}
+	case *ast.BlockStmt:
+		n.Statements = prune(n.Statements)
+	case *ast.FunctionStmt:
+		n.Body = prune(n.Body)
+	}
+	return node
+}
+
+// empty returns an empty block replacing stmt, which is removed from its enclosing
+// block by prune. Statements can't be removed where a single statement is expected.
+func empty(stmt ast.Stmt) *ast.BlockStmt {
+	return &ast.BlockStmt{Lbrace: stmt.Pos(), Rbrace: stmt.End()}
+}
+
+// prune removes empty blocks and unreachable statements from statements.
+func prune(statements []ast.Stmt) []ast.Stmt {
+	res := statements[:0]
+	for _, stmt := range statements {
+		if block, ok := stmt.(*ast.BlockStmt); ok && len(block.Statements) == 0 {
+			continue
+		}
+		res = append(res, stmt)
+		if _, ok := stmt.(*ast.ReturnStmt); ok {
+			break
+		}
+	}
+	return res
+}
+
+func foldUnary(expr *ast.UnaryExpr) ast.Expr {
+	v, ok := constant(expr.Right)
+	if !ok {
+		return expr
+	}
+	switch expr.Operator {
+	case token.Bang:
+		return literal(&valuer.Boolean{Value: !valuer.IsTruthy(v)}, expr.OpPos)
+	case token.Minus:
+		if n, ok := v.(*valuer.Number); ok {
+			return literal(&valuer.Number{Value: -n.Value}, expr.OpPos)
+		}
+	}
+	return expr
+}
+
+func foldBinary(expr *ast.BinaryExpr) ast.Expr {
+	left, ok := constant(expr.Left)
+	right, ok1 := constant(expr.Right)
+	if !ok || !ok1 {
+		return expr
+	}
+	pos := expr.Pos()
+	switch expr.Operator {
+	case token.EqualEqual:
+		return literal(&valuer.Boolean{Value: valuer.Equal(left, right)}, pos)
+	case token.BangEqual:
+		return literal(&valuer.Boolean{Value: !valuer.Equal(left, right)}, pos)
+	case token.Plus:
+		if s, ok := left.(*valuer.String); ok {
+			switch right.(type) {
+			case *valuer.Number, *valuer.String:
+				return literal(&valuer.String{Value: s.Value + right.String()}, pos)
+			}
+			return expr
+		}
+		if s, ok := right.(*valuer.String); ok {
+			if n, ok := left.(*valuer.Number); ok {
+				return literal(&valuer.String{Value: n.String() + s.Value}, pos)
+			}
+			return expr
+		}
+	}
+
+	a, ok := left.(*valuer.Number)
+	b, ok1 := right.(*valuer.Number)
+	if !ok || !ok1 {
+		return expr
+	}
+	var v valuer.Valuer
+	switch expr.Operator {
+	case token.Greater:
+		v = &valuer.Boolean{Value: a.Value > b.Value}
+	case token.GreaterEqual:
+		v = &valuer.Boolean{Value: a.Value >= b.Value}
+	case token.Less:
+		v = &valuer.Boolean{Value: a.Value < b.Value}
+	case token.LessEqual:
+		v = &valuer.Boolean{Value: a.Value <= b.Value}
+	case token.Minus:
+		v = &valuer.Number{Value: a.Value - b.Value}
+	case token.Plus:
+		v = &valuer.Number{Value: a.Value + b.Value}
+	case token.Slash:
+		if b.Value == 0 {
+			return expr
+		}
+		v = &valuer.Number{Value: a.Value / b.Value}
+	case token.Star:
+		v = &valuer.Number{Value: a.Value * b.Value}
+	default:
+		return expr
+	}
+	return literal(v, pos)
+}
+
+// foldInterpolation joins literal expressions of expr with the strings around them.
+// It returns a string literal if all expressions are literals.
+func foldInterpolation(expr *ast.InterpolationExpr) ast.Expr {
+	strs, exprs := expr.Strings[:1], expr.Exprs[:0]
+	for i, e := range expr.Exprs {
+		if v, ok := constant(e); ok {
+			strs[len(strs)-1] += v.String() + expr.Strings[i+1]
+			continue
+		}
+		strs = append(strs, expr.Strings[i+1])
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 0 {
+		return literal(&valuer.String{Value: strs[0]}, expr.Quote)
+	}
+	expr.Strings, expr.Exprs = strs, exprs
+	return expr
+}
+
+// constant returns the value of expr if it is a valid literal.
+func constant(expr ast.Expr) (valuer.Valuer, bool) {
+	lit, ok := expr.(*ast.Literal)
+	if !ok {
+		return nil, false
+	}
+	switch lit.Token {
+	case token.True:
+		return &valuer.Boolean{Value: true}, true
+	case token.False:
+		return &valuer.Boolean{Value: false}, true
+	case token.Nil:
+		return &valuer.Nil{}, true
+	case token.String:
+		return &valuer.String{Value: lit.Value}, true
+	case token.Number:
+		v, err := strconv.ParseFloat(lit.Value, 64)
+		if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+			return nil, false
+		}
+		return &valuer.Number{Value: v}, true
+	}
+	return nil, false
+}
+
+// literal returns the literal of v at pos.
+func literal(v valuer.Valuer, pos token.Position) *ast.Literal {
+	switch v := v.(type) {
+	case *valuer.Boolean:
+		if v.Value {
+			return &ast.Literal{ValuePos: pos, Token: token.True, Value: "true"}
+		}
+		return &ast.Literal{ValuePos: pos, Token: token.False, Value: "false"}
+	case *valuer.Number:
+		return &ast.Literal{ValuePos: pos, Token: token.Number, Value: strconv.FormatFloat(v.Value, 'g', -1, 64)}
+	case *valuer.String:
+		return &ast.Literal{ValuePos: pos, Token: token.String, Value: v.Value}
+	}
+	return &ast.Literal{ValuePos: pos, Token: token.Nil, Value: "nil"}
+}
@@ -0,0 +1,67 @@
+package optimizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"print 60 * 60 * 24;", "print 86400;"},
+		{"print (1 + 2) * -3 / 2;", "print -4.5;"},
+		{`print "a" + 1 + "b" + "c";`, "print a1bc;"},
+		{`print 1 + "a";`, "print 1a;"},
+		{"print 1 < 2 == true;", "print true;"},
+		{"print 1 == true;", "print true;"},
+		{`print nil == nil != ("" == 0);`, "print true;"},
+		{"print !nil;", "print true;"},
+		{"print 1e999 * 2;", "print +Inf;"},
+		{`print "a${1 + 2}b${nil}${"c"}";`, "print a3bnilc;"},
+		{`print "${1}${a}${2 > 1}${b}";`, "print 1${a}true${b};"},
+
+		// operations failing at run time are kept.
+		{"print 1 / 0;", "print (1 / 0);"},
+		{`print -"a";`, "print (-a);"},
+		{`print 1 + nil;`, "print (1 + null);"},
+		{`print "a" + true;`, "print (a + true);"},
+		{"print 1 < nil;", "print (1 < null);"},
+		{"print a + 2 * 3;", "print (a + 6);"},
+
+		{"print nil or a;", "print a;"},
+		{"print 2 or a;", "print 2;"},
+		{`print "" and a;`, "print ;"},
+		{"print 1 and a or b;", "print a or b;"},
+		{"print a or 1;", "print a or 1;"},
+
+		{"if (1 > 2) print a; else print b;", "print b;"},
+		{"if (1 < 2) { print a; } else print b;", "{ print a; }"},
+		{"if (false) print a; print b;", "print b;"},
+		{"if (a) if (false) print a;", "if (a) {  }"},
+		{"while (0) print a; while (a) print a;", "while (a) print a;"},
+		{"{ while (nil) {} } {}", ""},
+		{"fun f() { print a; return 1; print b; }", "fun f() { print a;return 1; }"},
+	}
+	for i, tt := range tests {
+		statements, err := parser.ParseStmts(tt.input)
+		if err != nil {
+			t.Fatalf("test [%d]: parse failed. error: %s", i, err)
+		}
+		var got []string
+		for _, stmt := range Optimize(statements) {
+			s := stmt.String()
+			if fn, ok := stmt.(*ast.FunctionStmt); ok {
+				s = "fun " + fn.Name + "() " + (&ast.BlockStmt{Statements: fn.Body}).String()
+			}
+			got = append(got, s)
+		}
+		if s := strings.Join(got, " "); s != tt.expected {
+			t.Errorf("test [%d]: expected %q. got %q", i, tt.expected, s)
+		}
+	}
+}
@@ -1,10 +1,24 @@
 package parser
 
+import "github.com/ziyoung/lox-go/token"
+
 // parseError implements error interface.
 type parseError struct {
-	s string
+	s   string
+	msg string
+	pos token.Position
 }
 
 func (p *parseError) Error() string {
 	return p.s
 }
+
+// Pos returns position of the token where the error occurs.
+func (p *parseError) Pos() token.Position {
+	return p.pos
+}
+
+// Message returns the error message without position.
+func (p *parseError) Message() string {
+	return p.msg
+}
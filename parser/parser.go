@@ -16,6 +16,9 @@ type Parser struct {
 
 	tok token.Token
 	lit string
+	pos token.Position
+
+	comments []*ast.Comment
 
 	trace  bool
 	indent int
@@ -26,11 +29,21 @@ func (p *Parser) nextToken() token.Token {
 		return token.EOF
 	}
 	tok, lit := p.l.NextToken()
+	for tok == token.Comment {
+		p.comments = append(p.comments, &ast.Comment{Slash: p.l.TokenPos(), Text: lit})
+		tok, lit = p.l.NextToken()
+	}
 	p.tok = tok
 	p.lit = lit
+	p.pos = p.l.TokenPos()
 	return tok
 }
 
+// Comments returns comments which have been read.
+func (p *Parser) Comments() []*ast.Comment {
+	return p.comments
+}
+
 // Parse returns all statements of input.
 func (p *Parser) Parse() (statements []ast.Stmt, err error) {
 	defer func() {
@@ -52,52 +65,64 @@ func (p *Parser) Parse() (statements []ast.Stmt, err error) {
 }
 
 func (p *Parser) parseDeclaration() ast.Stmt {
+	pos := p.pos
 	if p.match(token.Var) {
-		return p.parseVarDeclaration()
+		return p.parseVarDeclaration(pos)
 	}
 	if p.match(token.Fun) {
-		return p.parseFunDeclaration()
+		return p.parseFunDeclaration(pos)
 	}
 	if p.match(token.Class) {
-		return p.parseClassDeclaration()
+		return p.parseClassDeclaration(pos)
+	}
+	if p.match(token.Import) {
+		return p.parseImportDeclaration(pos)
+	}
+	if p.match(token.From) {
+		return p.parseFromImportDeclaration(pos)
 	}
 	return p.parseStatement()
 }
 
-func (p *Parser) parseVarDeclaration() *ast.VarStmt {
-	name := p.lit
+func (p *Parser) parseVarDeclaration(pos token.Position) *ast.VarStmt {
+	name, namePos := p.lit, p.pos
 	p.expect(token.Identifier, "Expect variable name.")
 	var stmt = &ast.VarStmt{
+		VarPos: pos,
 		Name: &ast.Ident{
-			Name: name,
+			NamePos: namePos,
+			Name:    name,
 		},
 	}
 	var initializer ast.Expr
 	if p.match(token.Equal) {
 		initializer = p.parseExpression()
 	}
+	stmt.Semicolon = p.pos
 	p.expect(token.Semicolon, "Expect ';' after variable declaration.")
 	stmt.Initializer = initializer
 	return stmt
 }
 
-func (p *Parser) parseFunDeclaration() *ast.FunctionStmt {
-	name := p.lit
+func (p *Parser) parseFunDeclaration(pos token.Position) *ast.FunctionStmt {
+	name, namePos := p.lit, p.pos
 	p.expect(token.Identifier, "Expect function name.")
 	p.expect(token.LeftParen, "Expect '(' after function name.")
 	fun := &ast.FunctionStmt{
-		Name:   name,
-		Params: make([]*ast.Ident, 0),
-		Body:   make([]ast.Stmt, 0),
+		FunPos:  pos,
+		NamePos: namePos,
+		Name:    name,
+		Params:  make([]*ast.Ident, 0),
+		Body:    make([]ast.Stmt, 0),
 	}
 	if !p.match(token.RightParen) {
 		for {
-			lit := p.lit
+			lit, litPos := p.lit, p.pos
 			p.expect(token.Identifier, "Expect parameter name.")
 			if len(fun.Params) >= 255 {
 				p.error("Cannot have more than 255 parameters.")
 			}
-			ident := &ast.Ident{Name: lit}
+			ident := &ast.Ident{NamePos: litPos, Name: lit}
 			fun.Params = append(fun.Params, ident)
 			if !p.match(token.Comma) {
 				break
@@ -106,62 +131,104 @@ func (p *Parser) parseFunDeclaration() *ast.FunctionStmt {
 		// return fun
 		p.expect(token.RightParen, "Expect ')' after parameters.")
 	}
+	lbrace := p.pos
 	p.expect(token.LeftBrace, "Expect '{' before function body.")
-	fun.Body = p.parseBlockStatement().Statements
+	body := p.parseBlockStatement(lbrace)
+	fun.Body = body.Statements
+	fun.Rbrace = body.Rbrace
 	return fun
 }
 
-func (p *Parser) parseClassDeclaration() *ast.ClassStmt {
-	name := p.lit
+func (p *Parser) parseClassDeclaration(pos token.Position) *ast.ClassStmt {
+	name, namePos := p.lit, p.pos
 	p.expect(token.Identifier, "Expect class name.")
 	p.expect(token.LeftBrace, "Expect '{' after class name.")
 
 	methods := make([]*ast.FunctionStmt, 0)
 	for p.check(token.Identifier) {
-		method := p.parseFunDeclaration()
+		method := p.parseFunDeclaration(p.pos)
 		method.IsInitializer = method.Name == "init"
 		methods = append(methods, method)
 	}
 
+	rbrace := p.pos
 	p.expect(token.RightBrace, "Expect '}' after class block.")
 
 	return &ast.ClassStmt{
-		Name:    name,
-		Methods: methods,
+		ClassPos: pos,
+		NamePos:  namePos,
+		Name:     name,
+		Methods:  methods,
+		Rbrace:   rbrace,
+	}
+}
+
+func (p *Parser) parseImportDeclaration(pos token.Position) *ast.ImportStmt {
+	stmt := &ast.ImportStmt{ImportPos: pos, PathPos: p.pos, Path: p.lit}
+	p.expect(token.String, "Expect module path after 'import'.")
+	if !(p.check(token.Identifier) && p.lit == "as") {
+		p.error("Expect 'as' after module path.")
+	}
+	p.nextToken()
+	stmt.Name = &ast.Ident{NamePos: p.pos, Name: p.lit}
+	p.expect(token.Identifier, "Expect module name after 'as'.")
+	stmt.Semicolon = p.pos
+	p.expect(token.Semicolon, "Expect ';' after import.")
+	return stmt
+}
+
+func (p *Parser) parseFromImportDeclaration(pos token.Position) *ast.ImportStmt {
+	stmt := &ast.ImportStmt{ImportPos: pos, PathPos: p.pos, Path: p.lit}
+	p.expect(token.String, "Expect module path after 'from'.")
+	p.expect(token.Import, "Expect 'import' after module path.")
+	for {
+		name, namePos := p.lit, p.pos
+		p.expect(token.Identifier, "Expect name to import.")
+		stmt.Names = append(stmt.Names, &ast.Ident{NamePos: namePos, Name: name})
+		if !p.match(token.Comma) {
+			break
+		}
 	}
+	stmt.Semicolon = p.pos
+	p.expect(token.Semicolon, "Expect ';' after import.")
+	return stmt
 }
 
 func (p *Parser) parseStatement() ast.Stmt {
+	pos := p.pos
 	if p.match(token.Print) {
-		return p.parsePrintStatement()
+		return p.parsePrintStatement(pos)
 	}
 	if p.match(token.If) {
-		return p.parseIfStatement()
+		return p.parseIfStatement(pos)
 	}
 	if p.match(token.While) {
-		return p.parseWhileStatement()
+		return p.parseWhileStatement(pos)
 	}
 	if p.match(token.For) {
-		return p.parseForStatement()
+		return p.parseForStatement(pos)
 	}
 	if p.match(token.LeftBrace) {
-		return p.parseBlockStatement()
+		return p.parseBlockStatement(pos)
 	}
 	if p.match(token.Return) {
-		return p.parseReturnStatement()
+		return p.parseReturnStatement(pos)
 	}
 	return p.parseExprStatement()
 }
 
-func (p *Parser) parsePrintStatement() ast.Stmt {
+func (p *Parser) parsePrintStatement(pos token.Position) ast.Stmt {
 	expr := p.parseExpression()
+	semicolon := p.pos
 	p.expect(token.Semicolon, "Expect ';' after value.")
 	return &ast.PrintStmt{
+		PrintPos:   pos,
 		Expression: expr,
+		Semicolon:  semicolon,
 	}
 }
 
-func (p *Parser) parseIfStatement() ast.Stmt {
+func (p *Parser) parseIfStatement(pos token.Position) ast.Stmt {
 	p.expect(token.LeftParen, "Expect '(' after 'if'.")
 	condition := p.parseExpression()
 	p.expect(token.RightParen, "Expect ')' after if condition.")
@@ -171,29 +238,31 @@ func (p *Parser) parseIfStatement() ast.Stmt {
 		elseBranch = p.parseStatement()
 	}
 	return &ast.IfStmt{
+		IfPos:      pos,
 		Condition:  condition,
 		ThenBranch: thenBranch,
 		ElseBranch: elseBranch,
 	}
 }
 
-func (p *Parser) parseWhileStatement() ast.Stmt {
+func (p *Parser) parseWhileStatement(pos token.Position) ast.Stmt {
 	p.expect(token.LeftParen, "Expect '(' after 'while'.")
 	condition := p.parseExpression()
 	p.expect(token.RightParen, "Expect ')' after while condition.")
 	body := p.parseStatement()
 	return &ast.WhileStmt{
+		WhilePos:  pos,
 		Condition: condition,
 		Body:      body,
 	}
 }
 
-func (p *Parser) parseForStatement() ast.Stmt {
+func (p *Parser) parseForStatement(pos token.Position) ast.Stmt {
 	p.expect(token.LeftParen, "Expect '(' after 'for'.")
 	var initializer ast.Stmt
 	if !p.match(token.Semicolon) {
-		if p.match(token.Var) {
-			initializer = p.parseVarDeclaration()
+		if varPos := p.pos; p.match(token.Var) {
+			initializer = p.parseVarDeclaration(varPos)
 		} else {
 			initializer = p.parseExprStatement()
 		}
@@ -213,61 +282,45 @@ func (p *Parser) parseForStatement() ast.Stmt {
 
 	body := p.parseStatement()
 
-	if increment != nil {
-		body = &ast.BlockStmt{
-			Statements: []ast.Stmt{
-				body,
-				&ast.ExprStmt{
-					Expression: increment,
-				},
-			},
-		}
-	}
-	if condition == nil {
-		condition = &ast.Literal{
-			Token: token.True,
-			Value: "true",
-		}
+	return &ast.ForStmt{
+		ForPos:      pos,
+		Initializer: initializer,
+		Condition:   condition,
+		Increment:   increment,
+		Body:        body,
 	}
-	body = &ast.WhileStmt{
-		Condition: condition,
-		Body:      body,
-	}
-
-	if initializer != nil {
-		body = &ast.BlockStmt{
-			Statements: []ast.Stmt{
-				initializer,
-				body,
-			},
-		}
-	}
-	return body
 }
 
-func (p *Parser) parseBlockStatement() *ast.BlockStmt {
+func (p *Parser) parseBlockStatement(lbrace token.Position) *ast.BlockStmt {
 	statements := make([]ast.Stmt, 0)
 	for !(p.check(token.RightBrace) || p.isAtEnd()) {
 		statements = append(statements, p.parseDeclaration())
 	}
+	rbrace := p.pos
 	p.expect(token.RightBrace, "Expect '}' after block.")
 	return &ast.BlockStmt{
+		Lbrace:     lbrace,
 		Statements: statements,
+		Rbrace:     rbrace,
 	}
 }
 
 func (p *Parser) parseExprStatement() ast.Stmt {
 	expr := p.parseExpression()
+	semicolon := p.pos
 	p.expect(token.Semicolon, "Expect ';' after expression.")
 	return &ast.ExprStmt{
 		Expression: expr,
+		Semicolon:  semicolon,
 	}
 }
 
-func (p *Parser) parseReturnStatement() ast.Stmt {
-	stmt := &ast.ReturnStmt{}
+func (p *Parser) parseReturnStatement(pos token.Position) ast.Stmt {
+	stmt := &ast.ReturnStmt{ReturnPos: pos, Keyword: token.Return}
+	stmt.Semicolon = p.pos
 	if !p.match(token.Semicolon) {
 		stmt.Value = p.parseExpression()
+		stmt.Semicolon = p.pos
 		p.expect(token.Semicolon, "Expect ';' after return value.")
 	}
 	return stmt
@@ -292,9 +345,10 @@ func (p *Parser) parseAssignment() ast.Expr {
 			}
 		case *ast.GetExpr:
 			return &ast.SetExpr{
-				Object: e.Object,
-				Name:   e.Name,
-				Value:  v,
+				Object:  e.Object,
+				NamePos: e.NamePos,
+				Name:    e.Name,
+				Value:   v,
 			}
 		}
 	}
@@ -303,7 +357,7 @@ func (p *Parser) parseAssignment() ast.Expr {
 
 func (p *Parser) parseOr() ast.Expr {
 	expr := p.parseAnd()
-	if p.match(token.Or) {
+	for p.match(token.Or) {
 		right := p.parseAnd()
 		expr = &ast.LogicalExpr{
 			Left:     expr,
@@ -316,7 +370,7 @@ func (p *Parser) parseOr() ast.Expr {
 
 func (p *Parser) parseAnd() ast.Expr {
 	expr := p.parseEquality()
-	if p.match(token.And) {
+	for p.match(token.And) {
 		right := p.parseEquality()
 		expr = &ast.LogicalExpr{
 			Left:     expr,
@@ -388,10 +442,11 @@ func (p *Parser) parseMultiplacation() ast.Expr {
 }
 
 func (p *Parser) parseUnary() ast.Expr {
-	operator := p.tok
+	operator, pos := p.tok, p.pos
 	if p.match(token.Bang, token.Minus) {
 		right := p.parseUnary()
 		return &ast.UnaryExpr{
+			OpPos:    pos,
 			Operator: operator,
 			Right:    right,
 		}
@@ -407,9 +462,9 @@ func (p *Parser) parseCall() ast.Expr {
 		if p.match(token.LeftParen) {
 			expr = p.finishCall(expr)
 		} else if p.match(token.Dot) {
-			name := p.lit
+			name, pos := p.lit, p.pos
 			p.expect(token.Identifier, "Expect property name after '.'.")
-			expr = &ast.GetExpr{Object: expr, Name: name}
+			expr = &ast.GetExpr{Object: expr, NamePos: pos, Name: name}
 		} else {
 			break
 		}
@@ -440,27 +495,32 @@ func (p *Parser) finishCall(expr ast.Expr) ast.Expr {
 }
 
 func (p *Parser) parsePrimary() (expr ast.Expr) {
-	tok, lit := p.tok, p.lit
+	tok, lit, pos := p.tok, p.lit, p.pos
 	switch tok {
 	default:
 		p.error("Expect expression.")
 	case token.True, token.False, token.Nil, token.String, token.Number:
 		expr = &ast.Literal{
-			Token: tok,
-			Value: lit,
+			ValuePos: pos,
+			Token:    tok,
+			Value:    lit,
 		}
 	case token.Identifier:
 		expr = &ast.VariableExpr{
+			NamePos:  pos,
 			Name:     lit,
 			Distance: -1,
 		}
 	case token.This:
-		expr = &ast.ThisExpr{}
+		expr = &ast.ThisExpr{ThisPos: pos}
+	case token.Interpolation:
+		return p.parseInterpolation()
 	case token.LeftParen:
 		p.nextToken()
 		inner := p.parseExpression()
 		p.expect(token.RightParen, "Expect ) after expression.")
 		expr = &ast.GroupingExpr{
+			Lparen:     pos,
 			Expression: inner,
 		}
 		return
@@ -469,13 +529,31 @@ func (p *Parser) parsePrimary() (expr ast.Expr) {
 	return expr
 }
 
+// parseInterpolation parses a string with embedded expressions. The lexer returns
+// each part of the string before an expression as token.Interpolation, and the
+// last part as token.String.
+func (p *Parser) parseInterpolation() ast.Expr {
+	expr := &ast.InterpolationExpr{Quote: p.pos}
+	for p.tok == token.Interpolation {
+		expr.Strings = append(expr.Strings, p.lit)
+		p.nextToken()
+		expr.Exprs = append(expr.Exprs, p.parseExpression())
+		if p.tok != token.Interpolation && p.tok != token.String {
+			p.error("Expect '}' after expression in string.")
+		}
+	}
+	expr.Strings = append(expr.Strings, p.lit)
+	p.nextToken()
+	return expr
+}
+
 func (p *Parser) synchronize() {
 	for !p.isAtEnd() {
 		switch p.tok {
 		case token.Semicolon:
 			p.nextToken()
 			return
-		case token.Class, token.Fun, token.Var, token.If, token.While, token.Print, token.Return:
+		case token.Class, token.Fun, token.Var, token.If, token.While, token.Print, token.Return, token.Import, token.From:
 			return
 		}
 		p.nextToken()
@@ -501,9 +579,9 @@ func (p *Parser) expect(tok token.Token, msg string) {
 }
 
 func (p *Parser) error(msg string) {
-	s := fmt.Sprintf("%s %s", p.l.Pos(), msg)
+	s := fmt.Sprintf("%s %s", p.pos, msg)
 	fmt.Fprintln(os.Stderr, s)
-	panic(parseError{s})
+	panic(parseError{s: s, msg: msg, pos: p.pos})
 }
 
 func (p *Parser) check(tok token.Token) bool {
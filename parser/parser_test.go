@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/bench/workload"
 	"github.com/ziyoung/lox-go/lexer"
 )
 
@@ -84,6 +87,30 @@ func TestParseSetExpr(t *testing.T) {
 	testExpr(t, tests)
 }
 
+func TestParseInterpolation(t *testing.T) {
+	tests := []parserTest{
+		{
+			input:    `"x = ${x}"`,
+			expected: "x = ${x}",
+		},
+		{
+			input:    `"${a + b}, ${f("${c}")}!"`,
+			expected: "${(a + b)}, ${f(${c})}!",
+		},
+		{
+			input:    `"a" + "${b}"`,
+			expected: "(a + ${b})",
+		},
+	}
+	testExpr(t, tests)
+
+	for _, input := range []string{`print "${}";`, `print "${a b}";`, `print "${a`} {
+		if _, err := ParseStmts(input); err == nil {
+			t.Errorf("parse of %q doesn't fail", input)
+		}
+	}
+}
+
 func TestParseExpressionRecover(t *testing.T) {
 	input := "123 + 456 -;123+456"
 	expected := "(123 + 456)"
@@ -236,6 +263,68 @@ func TestParseClass(t *testing.T) {
 	testAstString(t, input, expected)
 }
 
+func TestParseImport(t *testing.T) {
+	input := `import "lib/math.lox" as math;
+	from "math" import sin, cos;`
+	expected := []string{
+		`import "lib/math.lox" as math;`,
+		`from "math" import sin, cos;`,
+	}
+	testAstString(t, input, expected)
+
+	tests := []parserTest{
+		{`import math;`, "1:8 Expect module path after 'import'."},
+		{`import "math";`, "1:14 Expect 'as' after module path."},
+		{`import "math" as;`, "1:17 Expect module name after 'as'."},
+		{`from "math" sin;`, "1:13 Expect 'import' after module path."},
+		{`from "math" import sin,;`, "1:24 Expect name to import."},
+	}
+	for i, test := range tests {
+		_, err := newParserFromInput(test.input).Parse()
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("test [%d]: expected error %q. got %v", i, test.expected, err)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	input := `// comment
+var a = 1;
+fun f(x) {
+  return x;
+}`
+	p := newParserFromInput(input)
+	statements, err := p.Parse()
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err.Error())
+	}
+	tests := []struct {
+		node     ast.Node
+		pos, end string
+	}{
+		{statements[0], "2:1", "2:10"},
+		{statements[0].(*ast.VarStmt).Name, "2:5", ""},
+		{statements[0].(*ast.VarStmt).Initializer, "2:9", ""},
+		{statements[1], "3:1", "5:1"},
+		{statements[1].(*ast.FunctionStmt).Params[0], "3:7", ""},
+		{statements[1].(*ast.FunctionStmt).Body[0], "4:3", "4:11"},
+	}
+	for i, test := range tests {
+		if pos := test.node.Pos().String(); pos != test.pos {
+			t.Errorf("test [%d]: expected position is %s. got %s", i, test.pos, pos)
+		}
+		if stmt, ok := test.node.(ast.Stmt); ok {
+			if end := stmt.End().String(); end != test.end {
+				t.Errorf("test [%d]: expected end is %s. got %s", i, test.end, end)
+			}
+		}
+	}
+	comments := p.Comments()
+	if len(comments) != 1 || comments[0].Text != "// comment" || comments[0].Pos().String() != "1:1" {
+		t.Errorf("comment is not collected. got %v", comments)
+	}
+}
+
 func newParserFromInput(input string) *Parser {
 	l := lexer.New(input)
 	return New(l)
@@ -288,3 +377,44 @@ func testAstString(t *testing.T, input string, expected []string) {
 		}
 	}
 }
+
+func FuzzParse(f *testing.F) {
+	files, err := filepath.Glob("../example/*.lox")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(b))
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		statements, err := New(lexer.New(input)).Parse()
+		if err == nil {
+			for _, stmt := range statements {
+				if stmt == nil {
+					t.Fatal("nil statement without error")
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	workloads, err := workload.Load("../bench/testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, w := range workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			b.SetBytes(int64(len(w.Src)))
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseStmts(w.Src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
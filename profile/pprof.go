@@ -0,0 +1,212 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// Field numbers of profile.proto.
+// See https://github.com/google/pprof/blob/main/proto/profile.proto.
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileTimeNanos         = 9
+	profileDurationNanos     = 10
+	profilePeriodType        = 11
+	profilePeriod            = 12
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// Wire types of protocol buffers.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// buffer encodes protocol buffer messages.
+type buffer struct {
+	data []byte
+}
+
+func (b *buffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *buffer) key(field, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+// int64 encodes a varint field. Zero is omitted as the default value.
+func (b *buffer) int64(field int, x int64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(uint64(x))
+}
+
+func (b *buffer) uint64(field int, x uint64) {
+	b.int64(field, int64(x))
+}
+
+// int64s encodes a packed repeated varint field.
+func (b *buffer) int64s(field int, xs []int64) {
+	var packed buffer
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	b.bytes(field, packed.data)
+}
+
+func (b *buffer) uint64s(field int, xs []uint64) {
+	var packed buffer
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytes(field, packed.data)
+}
+
+func (b *buffer) bytes(field int, p []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(p)))
+	b.data = append(b.data, p...)
+}
+
+func (b *buffer) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+// message encodes the message written by fn as a field.
+func (b *buffer) message(field int, fn func(m *buffer)) {
+	var m buffer
+	fn(&m)
+	b.bytes(field, m.data)
+}
+
+// encoder assigns IDs to strings, functions and locations of a profile.
+type encoder struct {
+	b         buffer
+	filename  int64
+	strings   map[string]int64
+	table     []string
+	functions map[string]uint64
+	locations map[loc]uint64
+}
+
+func (e *encoder) string(s string) int64 {
+	if i, ok := e.strings[s]; ok {
+		return i
+	}
+	i := int64(len(e.table))
+	e.strings[s] = i
+	e.table = append(e.table, s)
+	return i
+}
+
+func (e *encoder) function(name string, line int) uint64 {
+	if id, ok := e.functions[name]; ok {
+		return id
+	}
+	id := uint64(len(e.functions) + 1)
+	e.functions[name] = id
+	e.b.message(profileFunction, func(m *buffer) {
+		m.uint64(functionID, id)
+		m.int64(functionName, e.string(name))
+		m.int64(functionSystemName, e.string(name))
+		m.int64(functionFilename, e.filename)
+		m.int64(functionStartLine, int64(line))
+	})
+	return id
+}
+
+func (e *encoder) location(l loc) uint64 {
+	if id, ok := e.locations[l]; ok {
+		return id
+	}
+	function := e.function(l.function, l.line)
+	id := uint64(len(e.locations) + 1)
+	e.locations[l] = id
+	e.b.message(profileLocation, func(m *buffer) {
+		m.uint64(locationID, id)
+		m.message(locationLine, func(line *buffer) {
+			line.uint64(lineFunctionID, function)
+			line.int64(lineLine, int64(l.line))
+		})
+	})
+	return id
+}
+
+func (e *encoder) valueType(field int, typ, unit string) {
+	e.b.message(field, func(m *buffer) {
+		m.int64(valueTypeType, e.string(typ))
+		m.int64(valueTypeUnit, e.string(unit))
+	})
+}
+
+// Write writes the profile to w in the gzip compressed pprof format. Samples have
+// three values: calls, statements and time, which is the default sample type.
+func (p *Profiler) Write(w io.Writer) error {
+	e := &encoder{
+		strings:   map[string]int64{"": 0},
+		table:     []string{""},
+		functions: make(map[string]uint64),
+		locations: make(map[loc]uint64),
+	}
+	e.filename = e.string(p.filename)
+	e.valueType(profileSampleType, "calls", "count")
+	e.valueType(profileSampleType, "statements", "count")
+	e.valueType(profileSampleType, "time", "nanoseconds")
+	e.b.int64(profileDefaultSampleType, e.string("time"))
+	e.valueType(profilePeriodType, "time", "nanoseconds")
+	e.b.int64(profilePeriod, 1)
+	e.b.int64(profileTimeNanos, p.start.UnixNano())
+	e.b.int64(profileDurationNanos, int64(p.Duration()))
+
+	p.walk(func(n *node) {
+		if n.calls == 0 && n.statements == 0 && n.time == 0 {
+			return
+		}
+		var ids []uint64
+		for a := n; a != &p.root; a = a.parent {
+			ids = append(ids, e.location(a.loc))
+		}
+		e.b.message(profileSample, func(m *buffer) {
+			m.uint64s(sampleLocationID, ids)
+			m.int64s(sampleValue, []int64{n.calls, n.statements, int64(n.time)})
+		})
+	})
+	for _, s := range e.table {
+		e.b.string(profileStringTable, s)
+	}
+
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(e.b.data); err != nil {
+		return err
+	}
+	return z.Close()
+}
@@ -0,0 +1,278 @@
+// Package profile records where a Lox program spends time, per function and per
+// source line, using the hooks of the interpreter. Profiles are written in the
+// pprof format, so they can be inspected with go tool pprof.
+//
+// Time is measured between consecutive statements and calls, and charged to the
+// call stack of the statement being executed. Functions are named as in frames of
+// the interpreter, qualified by their classes and modules, such as math.Vector.add.
+package profile
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/interpreter"
+)
+
+// scriptFunction is the function name of top-level code in frames.
+const scriptFunction = "<script>"
+
+// modulePrefix starts the function name of top-level code of a module in frames.
+const modulePrefix = "<module "
+
+// loc is a source line in a function.
+type loc struct {
+	function string
+	line     int
+}
+
+// node is a call stack in the call tree. Its loc is the innermost location.
+type node struct {
+	loc      loc
+	parent   *node
+	children map[loc]*node
+	// calls is the number of calls entering the function at loc.
+	calls int64
+	// statements is the number of statements executed at loc.
+	statements int64
+	// time is the time spent at loc, excluding callees.
+	time time.Duration
+}
+
+func (n *node) child(l loc) *node {
+	if c, ok := n.children[l]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[loc]*node)
+	}
+	c := &node{loc: l, parent: n}
+	n.children[l] = c
+	return c
+}
+
+// sortedChildren returns children ordered by function and line.
+func (n *node) sortedChildren() []*node {
+	children := make([]*node, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		a, b := children[i].loc, children[j].loc
+		if a.function != b.function {
+			return a.function < b.function
+		}
+		return a.line < b.line
+	})
+	return children
+}
+
+// frame is an activation on the call stack of the profiled program.
+type frame struct {
+	function string
+	// node is the current node of the frame, nil until its first statement is executed.
+	node *node
+	// entered reports whether the call is not recorded yet.
+	entered bool
+}
+
+// Profiler records a profile of the program run between Start and Stop.
+type Profiler struct {
+	filename string
+	now      func() time.Time
+
+	root  node
+	stack []frame
+	start time.Time
+	last  time.Time
+	end   time.Time
+}
+
+// New returns a profiler for the program in the file filename.
+func New(filename string) *Profiler {
+	return &Profiler{filename: filename, now: time.Now}
+}
+
+// Start starts profiling by setting the hooks of the interpreter.
+func (p *Profiler) Start() {
+	p.start = p.now()
+	p.last = p.start
+	interpreter.SetHook(p.statement)
+	interpreter.SetCallHook(p.call)
+}
+
+// Stop stops profiling and removes the hooks.
+func (p *Profiler) Stop() {
+	interpreter.SetHook(nil)
+	interpreter.SetCallHook(nil)
+	p.charge()
+	p.end = p.last
+}
+
+// charge charges the time elapsed since the last event to the current node.
+func (p *Profiler) charge() {
+	now := p.now()
+	d := now.Sub(p.last)
+	p.last = now
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if n := p.stack[i].node; n != nil {
+			n.time += d
+			return
+		}
+	}
+}
+
+// parent returns the node the top frame is called from.
+func (p *Profiler) parent() *node {
+	for i := len(p.stack) - 2; i >= 0; i-- {
+		if n := p.stack[i].node; n != nil {
+			return n
+		}
+	}
+	return &p.root
+}
+
+func (p *Profiler) statement(stmt ast.Stmt) {
+	p.charge()
+	if len(p.stack) == 0 {
+		return
+	}
+	f := &p.stack[len(p.stack)-1]
+	f.node = p.parent().child(loc{f.function, stmt.Pos().Line})
+	f.node.statements++
+	if f.entered {
+		f.node.calls++
+		f.entered = false
+	}
+}
+
+func (p *Profiler) call(function string, returning bool) {
+	p.charge()
+	// pprof regards <...> as template arguments and removes them.
+	switch {
+	case len(p.stack) == 0 && function == scriptFunction:
+		function = filepath.Base(p.filename)
+	case strings.HasPrefix(function, modulePrefix):
+		function = strings.TrimSuffix(strings.TrimPrefix(function, modulePrefix), ">")
+	}
+	if !returning {
+		p.stack = append(p.stack, frame{function: function, entered: true})
+		return
+	}
+	if len(p.stack) == 0 {
+		return
+	}
+	if f := p.stack[len(p.stack)-1]; f.entered {
+		// the function has no statements.
+		p.parent().child(loc{f.function, 0}).calls++
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// Duration returns the time profiled.
+func (p *Profiler) Duration() time.Duration {
+	return p.end.Sub(p.start)
+}
+
+// Function is the profile of a function.
+type Function struct {
+	Name  string
+	Calls int64
+	// Flat is the time spent in the function, and Cum includes time spent in its callees.
+	Flat, Cum time.Duration
+}
+
+// Line is the profile of a source line.
+type Line struct {
+	Function string
+	Line     int
+	// Statements is the number of statements executed at the line.
+	Statements int64
+	Flat, Cum  time.Duration
+}
+
+// Functions returns profiles of functions, ordered by flat time descending.
+func (p *Profiler) Functions() []Function {
+	index := make(map[string]*Function)
+	var functions []*Function
+	get := func(name string) *Function {
+		f, ok := index[name]
+		if !ok {
+			f = &Function{Name: name}
+			index[name] = f
+			functions = append(functions, f)
+		}
+		return f
+	}
+	p.walk(func(n *node) {
+		f := get(n.loc.function)
+		f.Calls += n.calls
+		f.Flat += n.time
+		seen := make(map[string]bool)
+		for a := n; a != &p.root; a = a.parent {
+			// a recursive function is charged once.
+			if !seen[a.loc.function] {
+				seen[a.loc.function] = true
+				get(a.loc.function).Cum += n.time
+			}
+		}
+	})
+	result := make([]Function, len(functions))
+	for i, f := range functions {
+		result[i] = *f
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Flat > result[j].Flat
+	})
+	return result
+}
+
+// Lines returns profiles of source lines, ordered by flat time descending.
+func (p *Profiler) Lines() []Line {
+	index := make(map[loc]*Line)
+	var lines []*Line
+	get := func(l loc) *Line {
+		line, ok := index[l]
+		if !ok {
+			line = &Line{Function: l.function, Line: l.line}
+			index[l] = line
+			lines = append(lines, line)
+		}
+		return line
+	}
+	p.walk(func(n *node) {
+		line := get(n.loc)
+		line.Statements += n.statements
+		line.Flat += n.time
+		seen := make(map[loc]bool)
+		for a := n; a != &p.root; a = a.parent {
+			if !seen[a.loc] {
+				seen[a.loc] = true
+				get(a.loc).Cum += n.time
+			}
+		}
+	})
+	result := make([]Line, len(lines))
+	for i, line := range lines {
+		result[i] = *line
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Flat > result[j].Flat
+	})
+	return result
+}
+
+// walk calls fn for each node of the call tree in a deterministic order.
+func (p *Profiler) walk(fn func(n *node)) {
+	var visit func(n *node)
+	visit = func(n *node) {
+		for _, c := range n.sortedChildren() {
+			fn(c)
+			visit(c)
+		}
+	}
+	visit(&p.root)
+}
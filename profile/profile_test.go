@@ -0,0 +1,248 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/ziyoung/lox-go/interpreter"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+const program = `fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+fun nothing() {}
+for (var i = 0; i < 3; i = i + 1) {
+  nothing();
+}
+print fib(5);
+`
+
+// run profiles program with a clock advancing a millisecond on each event.
+func run(t *testing.T) *Profiler {
+	return runSource(t, program)
+}
+
+// runSource profiles src like run.
+func runSource(t *testing.T, src string) *Profiler {
+	statements, err := parser.ParseStmts(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New("test.lox")
+	clock := time.Unix(0, 0)
+	p.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	interpreter.Reset()
+	interpreter.SetOutput(&bytes.Buffer{})
+	defer interpreter.SetOutput(nil)
+	p.Start()
+	err = interpreter.Execute(statements)
+	p.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestFunctions(t *testing.T) {
+	p := run(t)
+	functions := make(map[string]Function)
+	var total time.Duration
+	for _, f := range p.Functions() {
+		functions[f.Name] = f
+		total += f.Flat
+	}
+	tests := []struct {
+		name  string
+		calls int64
+	}{
+		{"test.lox", 1},
+		{"fib", 15},
+		{"nothing", 3},
+	}
+	for _, tt := range tests {
+		f, ok := functions[tt.name]
+		if !ok {
+			t.Errorf("function %s not found", tt.name)
+			continue
+		}
+		if f.Calls != tt.calls {
+			t.Errorf("calls of %s wrong. expected=%d, got=%d", tt.name, tt.calls, f.Calls)
+		}
+	}
+	if total <= 0 || total > p.Duration() {
+		t.Errorf("total flat time wrong. duration=%s, got=%s", p.Duration(), total)
+	}
+	if cum := functions["test.lox"].Cum; cum != total {
+		t.Errorf("cum of top-level code wrong. expected=%s, got=%s", total, cum)
+	}
+	if fib := functions["fib"]; fib.Cum != fib.Flat {
+		t.Errorf("cum of fib wrong. expected=%s, got=%s", fib.Flat, fib.Cum)
+	}
+}
+
+func TestLines(t *testing.T) {
+	p := run(t)
+	statements := make(map[loc]int64)
+	for _, line := range p.Lines() {
+		statements[loc{line.Function, line.Line}] = line.Statements
+	}
+	tests := []struct {
+		loc        loc
+		statements int64
+	}{
+		{loc{"fib", 2}, 15 + 8}, // the if statement and return in base cases
+		{loc{"fib", 3}, 7},
+		{loc{"test.lox", 6}, 4},
+		{loc{"test.lox", 7}, 3},
+		{loc{"test.lox", 9}, 1},
+	}
+	for _, tt := range tests {
+		if got := statements[tt.loc]; got != tt.statements {
+			t.Errorf("statements at %s:%d wrong. expected=%d, got=%d", tt.loc.function, tt.loc.line, tt.statements, got)
+		}
+	}
+}
+
+func TestQualifiedNames(t *testing.T) {
+	interpreter.SetFile("../interpreter/testdata/modules/main.lox")
+	defer interpreter.SetFile("")
+	p := runSource(t, `import "math" as m;
+class A {
+  f() { return 1; }
+}
+class B {
+  f() { return 2; }
+}
+A().f();
+B().f();
+B().f();
+m.square(2);
+`)
+	calls := make(map[string]int64)
+	for _, f := range p.Functions() {
+		calls[f.Name] = f.Calls
+	}
+	tests := []struct {
+		name  string
+		calls int64
+	}{
+		{"A.f", 1},
+		{"B.f", 2},
+		{"math.square", 1},
+		{"math", 1},
+	}
+	for _, tt := range tests {
+		if got := calls[tt.name]; got != tt.calls {
+			t.Errorf("calls of %s wrong. expected=%d, got=%d (%v)", tt.name, tt.calls, got, calls)
+		}
+	}
+}
+
+// field is a decoded protocol buffer field.
+type field struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+func varint(b []byte) (uint64, []byte) {
+	var x uint64
+	for shift := uint(0); ; shift += 7 {
+		c := b[0]
+		b = b[1:]
+		x |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return x, b
+		}
+	}
+}
+
+func decode(b []byte) []field {
+	var fields []field
+	for len(b) > 0 {
+		var key, n uint64
+		key, b = varint(b)
+		f := field{num: int(key >> 3)}
+		if key&7 == wireVarint {
+			f.varint, b = varint(b)
+		} else {
+			n, b = varint(b)
+			f.bytes, b = b[:n], b[n:]
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func TestWrite(t *testing.T) {
+	p := run(t)
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	z, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var table []string
+	var samples [][]uint64
+	var defaultType uint64
+	for _, f := range decode(b) {
+		switch f.num {
+		case profileStringTable:
+			table = append(table, string(f.bytes))
+		case profileDefaultSampleType:
+			defaultType = f.varint
+		case profileSample:
+			for _, sf := range decode(f.bytes) {
+				if sf.num != sampleValue {
+					continue
+				}
+				var values []uint64
+				for rest := sf.bytes; len(rest) > 0; {
+					var v uint64
+					v, rest = varint(rest)
+					values = append(values, v)
+				}
+				samples = append(samples, values)
+			}
+		}
+	}
+	if len(table) == 0 || table[0] != "" {
+		t.Fatalf("string table wrong. got=%q", table)
+	}
+	if table[defaultType] != "time" {
+		t.Errorf("default sample type wrong. expected=time, got=%q", table[defaultType])
+	}
+	var calls, elapsed uint64
+	for _, values := range samples {
+		if len(values) != 3 {
+			t.Fatalf("sample values wrong. got=%v", values)
+		}
+		calls += values[0]
+		elapsed += values[2]
+	}
+	if calls != 19 {
+		t.Errorf("calls wrong. expected=19, got=%d", calls)
+	}
+	var total time.Duration
+	for _, f := range p.Functions() {
+		total += f.Flat
+	}
+	if time.Duration(elapsed) != total {
+		t.Errorf("time wrong. expected=%d, got=%d", total, elapsed)
+	}
+}
@@ -0,0 +1,185 @@
+package resolver
+
+import (
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/errors"
+	"github.com/ziyoung/lox-go/token"
+)
+
+// Kind represents kind of a binding.
+type Kind int
+
+const (
+	VarBinding Kind = iota
+	ParamBinding
+	FunctionBinding
+	ClassBinding
+	ImportBinding
+)
+
+var kinds = [...]string{
+	VarBinding:      "variable",
+	ParamBinding:    "parameter",
+	FunctionBinding: "function",
+	ClassBinding:    "class",
+	ImportBinding:   "import",
+}
+
+func (k Kind) String() string { return kinds[k] }
+
+// Binding represents a name declared in the program.
+type Binding struct {
+	Name string
+	Kind Kind
+	Pos  token.Position
+	// Decl is *ast.VarStmt, *ast.Ident (parameter), *ast.FunctionStmt, *ast.ClassStmt
+	// or *ast.ImportStmt.
+	Decl   ast.Node
+	Global bool
+	// Shadows is the binding with the same name in an enclosing scope.
+	Shadows *Binding
+	Reads   []*ast.VariableExpr
+	Writes  []*ast.AssignExpr
+}
+
+// Info records scope information found by Analyze.
+type Info struct {
+	Bindings []*Binding
+	// Uses maps *ast.VariableExpr and *ast.AssignExpr to the binding they refer to.
+	// References to globals which are not declared in the program are absent.
+	Uses map[ast.Expr]*Binding
+	// Undeclared are assignments to globals which are not declared in the program.
+	Undeclared []*ast.AssignExpr
+	// Calls are all call expressions in the program.
+	Calls []*ast.CallExpr
+	// Unreachable are statements which follow a return statement in the same block.
+	Unreachable []ast.Stmt
+	// FunctionThis are this expressions in plain functions nested in methods.
+	FunctionThis []*ast.ThisExpr
+}
+
+// recorder collects Info while resolving. It is nil unless Analyze is running.
+var recorder *infoRecorder
+
+type infoRecorder struct {
+	info    *Info
+	scopes  []map[string]*Binding
+	globals map[string]*Binding
+	// pending are references to globals, which are linked after all declarations are seen.
+	pending []ast.Expr
+}
+
+// Analyze resolves statements and returns scope information of them.
+func Analyze(statements []ast.Stmt) (info *Info, err error) {
+	Reset()
+	recorder = &infoRecorder{
+		info:    &Info{Uses: make(map[ast.Expr]*Binding)},
+		globals: make(map[string]*Binding),
+	}
+	defer func() {
+		recorder = nil
+		if r := recover(); r != nil {
+			if runErr, ok := r.(errors.RuntimeError); ok {
+				Reset()
+				info, err = nil, &runErr
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	for _, stmt := range statements {
+		Resolve(stmt)
+	}
+	recorder.finish()
+	return recorder.info, nil
+}
+
+func (r *infoRecorder) begin() {
+	r.scopes = append(r.scopes, make(map[string]*Binding))
+}
+
+func (r *infoRecorder) end() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *infoRecorder) declare(name string, kind Kind, pos token.Position, decl ast.Node) {
+	b := &Binding{Name: name, Kind: kind, Pos: pos, Decl: decl}
+	r.info.Bindings = append(r.info.Bindings, b)
+	if len(r.scopes) == 0 {
+		b.Global = true
+		if _, ok := r.globals[name]; !ok {
+			r.globals[name] = b
+		}
+		return
+	}
+	for i := len(r.scopes) - 2; i >= 0 && b.Shadows == nil; i-- {
+		b.Shadows = r.scopes[i][name]
+	}
+	r.scopes[len(r.scopes)-1][name] = b
+}
+
+// lookup returns binding of a local variable, or nil for a global one.
+func (r *infoRecorder) lookup(name string) *Binding {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if b, ok := r.scopes[i][name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+func (r *infoRecorder) read(expr *ast.VariableExpr) {
+	if b := r.lookup(expr.Name); b != nil {
+		r.info.Uses[expr] = b
+		b.Reads = append(b.Reads, expr)
+		return
+	}
+	r.pending = append(r.pending, expr)
+}
+
+func (r *infoRecorder) write(expr *ast.AssignExpr) {
+	if b := r.lookup(expr.Left.Name); b != nil {
+		r.info.Uses[expr] = b
+		b.Writes = append(b.Writes, expr)
+		return
+	}
+	r.pending = append(r.pending, expr)
+}
+
+// block records statements which follow a return statement.
+func (r *infoRecorder) block(statements []ast.Stmt) {
+	if len(statements) == 0 {
+		return
+	}
+	for i, stmt := range statements[:len(statements)-1] {
+		if _, ok := stmt.(*ast.ReturnStmt); ok {
+			r.info.Unreachable = append(r.info.Unreachable, statements[i+1])
+			return
+		}
+	}
+}
+
+// finish links references to globals and finds locals shadowing globals.
+func (r *infoRecorder) finish() {
+	for _, expr := range r.pending {
+		switch e := expr.(type) {
+		case *ast.VariableExpr:
+			if b, ok := r.globals[e.Name]; ok {
+				r.info.Uses[e] = b
+				b.Reads = append(b.Reads, e)
+			}
+		case *ast.AssignExpr:
+			if b, ok := r.globals[e.Left.Name]; ok {
+				r.info.Uses[e] = b
+				b.Writes = append(b.Writes, e)
+			} else {
+				r.info.Undeclared = append(r.info.Undeclared, e)
+			}
+		}
+	}
+	for _, b := range r.info.Bindings {
+		if !b.Global && b.Shadows == nil {
+			b.Shadows = r.globals[b.Name]
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package resolver
 
 import (
+	"fmt"
+
 	"github.com/ziyoung/lox-go/ast"
 	"github.com/ziyoung/lox-go/errors"
 	"github.com/ziyoung/lox-go/token"
@@ -31,7 +33,7 @@ var (
 func Resolve(node ast.Node) {
 	switch n := node.(type) {
 	default:
-		panic("Resolve failed: unknown ast type.")
+		errors.Error(token.EOF, fmt.Sprintf("Cannot resolve %T.", n))
 	case *ast.VariableExpr:
 		resolveVariableExpr(n)
 	case *ast.AssignExpr:
@@ -44,6 +46,8 @@ func Resolve(node ast.Node) {
 		resolveLogicalExpr(n)
 	case *ast.GroupingExpr:
 		resolveGroupExpr(n)
+	case *ast.InterpolationExpr:
+		resolveInterpolationExpr(n)
 	case *ast.CallExpr:
 		resolveCallExpr(n)
 	case *ast.GetExpr:
@@ -52,6 +56,8 @@ func Resolve(node ast.Node) {
 		resolveSetExpr(n)
 	case *ast.ThisExpr:
 		resolveThisExpr(n)
+	case *ast.SuperExpr:
+		resolveSuperExpr(n)
 	case *ast.Literal:
 		// do nothing.
 	case *ast.BlockStmt:
@@ -66,21 +72,51 @@ func Resolve(node ast.Node) {
 		resolveIfStmt(n)
 	case *ast.WhileStmt:
 		resolveWhileStmt(n)
+	case *ast.ForStmt:
+		resolveForStmt(n)
 	case *ast.PrintStmt:
 		resolvePrintStmt(n)
 	case *ast.ReturnStmt:
 		resolveReturnStmt(n)
 	case *ast.ClassStmt:
 		resolveClassStmt(n)
+	case *ast.ImportStmt:
+		resolveImportStmt(n)
+	}
+}
+
+// Reset discards the state left by a resolution failing in the middle.
+func Reset() {
+	scopes = NewScopes()
+	curFunctionType = FunctionNone
+	curClassType = ClassNone
+}
+
+// ResolveIn resolves node as if it appeared inside scopes, the innermost scope last.
+// A scope defining "this" is regarded as a class scope.
+func ResolveIn(node ast.Node, s []map[string]bool) {
+	previous, enclosingClass := scopes, curClassType
+	defer func() {
+		scopes, curClassType = previous, enclosingClass
+	}()
+	scopes = s
+	for _, scope := range s {
+		if scope["this"] {
+			curClassType = Class
+		}
 	}
+	Resolve(node)
 }
 
 func resolveVariableExpr(expr *ast.VariableExpr) {
 	if exist, init := scopes.check(expr.Name); exist && !init {
-		errors.Error(token.Identifier, "Cannot read local variable in its own initializer.")
+		errors.ErrorAt(expr.NamePos, token.Identifier, "Cannot read local variable in its own initializer.")
 		return
 	}
 	resolveLocal(expr, expr.Name)
+	if recorder != nil {
+		recorder.read(expr)
+	}
 }
 
 func resolveLocal(expr ast.Expr, name string) {
@@ -90,6 +126,7 @@ func resolveLocal(expr ast.Expr, name string) {
 		for i := len(scopes) - 1; i >= 0; i-- {
 			if _, ok := scopes[i][name]; ok {
 				n.Distance = len(scopes) - 1 - i
+				break
 			}
 		}
 	case *ast.ThisExpr:
@@ -101,7 +138,7 @@ func resolveLocal(expr ast.Expr, name string) {
 			}
 		}
 		if !exist {
-			errors.Error(token.This, "Cannot use 'this' outside of a class.")
+			errors.ErrorAt(n.ThisPos, token.This, "Cannot use 'this' outside of a class.")
 		}
 	}
 }
@@ -109,6 +146,9 @@ func resolveLocal(expr ast.Expr, name string) {
 func resolveAssignExpr(expr *ast.AssignExpr) {
 	Resolve(expr.Value)
 	resolveLocal(expr.Left, expr.Left.Name)
+	if recorder != nil {
+		recorder.write(expr)
+	}
 }
 
 func resolveBinaryExpr(expr *ast.BinaryExpr) {
@@ -129,7 +169,16 @@ func resolveGroupExpr(expr *ast.GroupingExpr) {
 	Resolve(expr.Expression)
 }
 
+func resolveInterpolationExpr(expr *ast.InterpolationExpr) {
+	for _, e := range expr.Exprs {
+		Resolve(e)
+	}
+}
+
 func resolveCallExpr(expr *ast.CallExpr) {
+	if recorder != nil {
+		recorder.info.Calls = append(recorder.info.Calls, expr)
+	}
 	Resolve(expr.Callee)
 
 	for _, arg := range expr.Arguments {
@@ -148,19 +197,34 @@ func resolveSetExpr(expr *ast.SetExpr) {
 
 func resolveThisExpr(expr *ast.ThisExpr) {
 	if curClassType == ClassNone {
-		errors.Error(token.This, "Cannot use 'this' outside of a class.")
+		errors.ErrorAt(expr.ThisPos, token.This, "Cannot use 'this' outside of a class.")
 		return
 	}
+	if recorder != nil && curFunctionType == Function {
+		recorder.info.FunctionThis = append(recorder.info.FunctionThis, expr)
+	}
 	resolveLocal(expr, "this")
 }
 
+// resolveSuperExpr reports super expressions, since classes can't inherit yet.
+func resolveSuperExpr(expr *ast.SuperExpr) {
+	if curClassType == ClassNone {
+		errors.ErrorAt(expr.KeywordPos, token.Super, "Cannot use 'super' outside of a class.")
+		return
+	}
+	errors.ErrorAt(expr.KeywordPos, token.Super, "Cannot use 'super' in a class with no superclass.")
+}
+
 func resolveBlockStmt(block *ast.BlockStmt) {
-	scopes.begin()
+	beginScope()
 	resolveBlock(block.Statements)
-	scopes.end()
+	endScope()
 }
 
 func resolveBlock(statements []ast.Stmt) {
+	if recorder != nil {
+		recorder.block(statements)
+	}
 	for _, stmt := range statements {
 		Resolve(stmt)
 	}
@@ -168,7 +232,7 @@ func resolveBlock(statements []ast.Stmt) {
 
 func resolveVarStmt(stmt *ast.VarStmt) {
 	name := stmt.Name.Name
-	scopes.declare(name)
+	declare(name, VarBinding, stmt.Name.NamePos, stmt)
 	if stmt.Initializer != nil {
 		Resolve(stmt.Initializer)
 	}
@@ -176,7 +240,7 @@ func resolveVarStmt(stmt *ast.VarStmt) {
 }
 
 func resolveFunctionStmt(stmt *ast.FunctionStmt) {
-	scopes.declare(stmt.Name)
+	declare(stmt.Name, FunctionBinding, stmt.NamePos, stmt)
 	scopes.define(stmt.Name)
 	resolveFunction(stmt, Function)
 }
@@ -188,13 +252,13 @@ func resolveFunction(function *ast.FunctionStmt, typ functionType) {
 		curFunctionType = enclosingFunction
 	}()
 
-	scopes.begin()
+	beginScope()
 	for _, param := range function.Params {
-		scopes.declare(param.Name)
+		declare(param.Name, ParamBinding, param.NamePos, param)
 		scopes.define(param.Name)
 	}
 	resolveBlock(function.Body)
-	scopes.end()
+	endScope()
 }
 
 func resolveExprStmt(stmt *ast.ExprStmt) {
@@ -214,26 +278,54 @@ func resolveWhileStmt(stmt *ast.WhileStmt) {
 	Resolve(stmt.Body)
 }
 
+func resolveForStmt(stmt *ast.ForStmt) {
+	beginScope()
+	if stmt.Initializer != nil {
+		Resolve(stmt.Initializer)
+	}
+	if stmt.Condition != nil {
+		Resolve(stmt.Condition)
+	}
+	if stmt.Increment != nil {
+		Resolve(stmt.Increment)
+	}
+	Resolve(stmt.Body)
+	endScope()
+}
+
 func resolvePrintStmt(stmt *ast.PrintStmt) {
 	Resolve(stmt.Expression)
 }
 
 func resolveReturnStmt(stmt *ast.ReturnStmt) {
 	if curFunctionType == FunctionNone {
-		errors.Error(token.Return, "Cannot return from top-level code.")
+		errors.ErrorAt(stmt.ReturnPos, token.Return, "Cannot return from top-level code.")
 		return
 	}
 	if stmt.Value != nil {
 		if curFunctionType == Initializer {
-			errors.Error(token.Return, "Cannot return a value from an initializer.")
+			errors.ErrorAt(stmt.ReturnPos, token.Return, "Cannot return a value from an initializer.")
 			return
 		}
 		Resolve(stmt.Value)
 	}
 }
 
+func resolveImportStmt(stmt *ast.ImportStmt) {
+	if !scopes.isEmpty() {
+		errors.ErrorAt(stmt.ImportPos, token.Import, "Can only import at top level.")
+		return
+	}
+	if stmt.Name != nil {
+		declare(stmt.Name.Name, ImportBinding, stmt.Name.NamePos, stmt)
+	}
+	for _, name := range stmt.Names {
+		declare(name.Name, ImportBinding, name.NamePos, stmt)
+	}
+}
+
 func resolveClassStmt(stmt *ast.ClassStmt) {
-	scopes.declare(stmt.Name)
+	declare(stmt.Name, ClassBinding, stmt.NamePos, stmt)
 	scopes.define(stmt.Name)
 
 	enclosingClass := curClassType
@@ -242,8 +334,8 @@ func resolveClassStmt(stmt *ast.ClassStmt) {
 		curClassType = enclosingClass
 	}()
 
-	scopes.begin()
-	scopes.declare("this")
+	beginScope()
+	scopes.declare("this", stmt.ClassPos)
 	scopes.define("this")
 	for _, method := range stmt.Methods {
 		typ := Method
@@ -252,5 +344,26 @@ func resolveClassStmt(stmt *ast.ClassStmt) {
 		}
 		resolveFunction(method, typ)
 	}
+	endScope()
+}
+
+func beginScope() {
+	scopes.begin()
+	if recorder != nil {
+		recorder.begin()
+	}
+}
+
+func endScope() {
 	scopes.end()
+	if recorder != nil {
+		recorder.end()
+	}
+}
+
+func declare(name string, kind Kind, pos token.Position, decl ast.Node) {
+	scopes.declare(name, pos)
+	if recorder != nil {
+		recorder.declare(name, kind, pos, decl)
+	}
 }
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/ziyoung/lox-go/ast"
+	"github.com/ziyoung/lox-go/bench/workload"
+	"github.com/ziyoung/lox-go/parser"
+)
+
+func TestResolveDistance(t *testing.T) {
+	input := `var a = 0;
+{
+  var a = 1;
+  {
+    var a = 2;
+    {
+      print a;
+    }
+    print a;
+  }
+  fun f() { print a; }
+}
+print a;`
+	statements, err := parser.ParseStmts(input)
+	if err != nil {
+		t.Fatalf("parse failed. error: %s", err)
+	}
+	Reset()
+	for _, stmt := range statements {
+		Resolve(stmt)
+	}
+	var distances []int
+	for _, stmt := range statements {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			if v, ok := node.(*ast.VariableExpr); ok {
+				distances = append(distances, v.Distance)
+			}
+			return true
+		})
+	}
+	// the innermost declaration is used, and -1 is a global.
+	expected := []int{1, 0, 1, -1}
+	if len(distances) != len(expected) {
+		t.Fatalf("expected distances %v. got %v", expected, distances)
+	}
+	for i := range expected {
+		if distances[i] != expected[i] {
+			t.Errorf("expected distances %v. got %v", expected, distances)
+			break
+		}
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	workloads, err := workload.Load("../bench/testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, w := range workloads {
+		statements, err := parser.ParseStmts(w.Src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(w.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Reset()
+				for _, stmt := range statements {
+					Resolve(stmt)
+				}
+			}
+		})
+	}
+}
@@ -13,8 +13,8 @@ type Scopes []map[string]bool
 func (s *Scopes) check(name string) (exist bool, init bool) {
 	if !s.isEmpty() {
 		scope := s.peek()
-		if _, ok := scope[name]; ok {
-			return true, ok
+		if defined, ok := scope[name]; ok {
+			return true, defined
 		}
 	}
 	return false, false
@@ -47,13 +47,13 @@ func (s Scopes) isEmpty() bool {
 	return len(s) == 0
 }
 
-func (s Scopes) declare(name string) {
+func (s Scopes) declare(name string, pos token.Position) {
 	if s.isEmpty() {
 		return
 	}
 	scope := s.peek()
 	if _, ok := scope[name]; ok {
-		errors.Error(token.Var, fmt.Sprintf("variable name %q has been already delcared in this scope.", name))
+		errors.ErrorAt(pos, token.Var, fmt.Sprintf("variable name %q has been already delcared in this scope.", name))
 	}
 	scope[name] = false
 }
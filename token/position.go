@@ -0,0 +1,22 @@
+package token
+
+import "fmt"
+
+// Position represents a position in source code.
+//
+// Its JSON encoding is an object with "offset", "line" and "column".
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // line number, starting at 1
+	Column int `json:"column"` // column number, starting at 1 (character count)
+}
+
+// IsValid reports whether the position is valid.
+func (pos Position) IsValid() bool { return pos.Line > 0 }
+
+func (pos Position) String() string {
+	if !pos.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
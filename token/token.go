@@ -1,6 +1,9 @@
 package token
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Token is a lexical token of lox programing language.
 type Token int
@@ -33,9 +36,10 @@ const (
 	Less         // <
 	LessEqual    // <=
 
-	Identifier // abc
-	String     // "abc"
-	Number     // 123
+	Identifier    // abc
+	String        // "abc"
+	Interpolation // "abc${
+	Number        // 123
 
 	keywordBegin
 
@@ -45,7 +49,9 @@ const (
 	False  // false
 	Fun    // fun
 	For    // for
+	From   // from
 	If     // if
+	Import // import
 	Nil    // nil
 	Or     // or
 	Print  // print
@@ -60,56 +66,67 @@ const (
 )
 
 var tokens = [...]string{
-	Illegal:      "illegal",
-	EOF:          "EOF",
-	Comment:      "comment",
-	LeftParen:    "(",
-	RightParen:   ")",
-	LeftBrace:    "{",
-	RightBrace:   "}",
-	Comma:        ",",
-	Dot:          ".",
-	Minus:        "-",
-	Plus:         "+",
-	Semicolon:    ";",
-	Slash:        "/",
-	Star:         "*",
-	Bang:         "!",
-	BangEqual:    "!=",
-	Equal:        "=",
-	EqualEqual:   "==",
-	Greater:      ">",
-	GreaterEqual: ">=",
-	Less:         "<",
-	LessEqual:    "<=",
-	Identifier:   "identifier",
-	String:       "string",
-	Number:       "number",
-	And:          "and",
-	Class:        "class",
-	Else:         "else",
-	False:        "false",
-	Fun:          "fun",
-	For:          "for",
-	If:           "if",
-	Nil:          "nil",
-	Or:           "or",
-	Print:        "print",
-	Return:       "return",
-	Super:        "super",
-	This:         "this",
-	True:         "true",
-	Var:          "var",
-	While:        "while",
+	Illegal:       "illegal",
+	EOF:           "EOF",
+	Comment:       "comment",
+	LeftParen:     "(",
+	RightParen:    ")",
+	LeftBrace:     "{",
+	RightBrace:    "}",
+	Comma:         ",",
+	Dot:           ".",
+	Minus:         "-",
+	Plus:          "+",
+	Semicolon:     ";",
+	Slash:         "/",
+	Star:          "*",
+	Bang:          "!",
+	BangEqual:     "!=",
+	Equal:         "=",
+	EqualEqual:    "==",
+	Greater:       ">",
+	GreaterEqual:  ">=",
+	Less:          "<",
+	LessEqual:     "<=",
+	Identifier:    "identifier",
+	String:        "string",
+	Interpolation: "interpolation",
+	Number:        "number",
+	And:           "and",
+	Class:         "class",
+	Else:          "else",
+	False:         "false",
+	Fun:           "fun",
+	For:           "for",
+	From:          "from",
+	If:            "if",
+	Import:        "import",
+	Nil:           "nil",
+	Or:            "or",
+	Print:         "print",
+	Return:        "return",
+	Super:         "super",
+	This:          "this",
+	True:          "true",
+	Var:           "var",
+	While:         "while",
 }
 
 var keywords = map[string]Token{}
 
+// names maps strings of tokens to tokens.
+var names = map[string]Token{}
+
 func init() {
 	i, j := int(keywordBegin)+1, int(keywordEnd)
 	for ; i < j; i++ {
 		keywords[tokens[i]] = Token(i)
 	}
+	for i, s := range tokens {
+		if s != "" {
+			names[s] = Token(i)
+		}
+	}
 }
 
 func (tok Token) String() string {
@@ -126,7 +143,11 @@ func (tok *Token) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
 	}
-	*tok = Lookup(s)
+	t, ok := names[s]
+	if !ok {
+		return fmt.Errorf("unknown token %q", s)
+	}
+	*tok = t
 	return nil
 }
 
@@ -135,6 +156,15 @@ func (tok Token) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tok.String())
 }
 
+// Keywords returns all keywords of lox programing language.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for i := int(keywordBegin) + 1; i < int(keywordEnd); i++ {
+		words = append(words, tokens[i])
+	}
+	return words
+}
+
 // Lookup returns the token type associated with a given string.
 func Lookup(ident string) Token {
 	if tok, ok := keywords[ident]; ok {
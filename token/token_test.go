@@ -1,6 +1,9 @@
 package token
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestIdentIsKeyword(t *testing.T) {
 	tests := []struct {
@@ -14,7 +17,9 @@ func TestIdentIsKeyword(t *testing.T) {
 		{"false", False},
 		{"fun", Fun},
 		{"for", For},
+		{"from", From},
 		{"if", If},
+		{"import", Import},
 		{"nil", Nil},
 		{"or", Or},
 		{"print", Print},
@@ -32,3 +37,47 @@ func TestIdentIsKeyword(t *testing.T) {
 		}
 	}
 }
+
+func TestKeywords(t *testing.T) {
+	words := Keywords()
+	if len(words) != len(keywords) {
+		t.Fatalf("expected %d keywords. got %d", len(keywords), len(words))
+	}
+	for _, word := range words {
+		if Lookup(word) == Identifier {
+			t.Errorf("%s should be a keyword", word)
+		}
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	for _, tok := range []Token{Illegal, EOF, Plus, LessEqual, Identifier, String, Number, While} {
+		b, err := tok.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Token
+		if err := got.UnmarshalJSON(b); err != nil || got != tok {
+			t.Errorf("%s: round trip failed. got %s, error: %v", b, got, err)
+		}
+	}
+	var tok Token
+	if err := tok.UnmarshalJSON([]byte(`"=>"`)); err == nil {
+		t.Errorf("unknown token is unmarshaled. got %s", tok)
+	}
+}
+
+func TestPositionJSON(t *testing.T) {
+	pos := Position{Offset: 10, Line: 2, Column: 3}
+	b, err := json.Marshal(pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"offset":10,"line":2,"column":3}` {
+		t.Errorf("position encoding wrong. got %s", b)
+	}
+	var got Position
+	if err := json.Unmarshal(b, &got); err != nil || got != pos {
+		t.Errorf("%s: round trip failed. got %v, error: %v", b, got, err)
+	}
+}
@@ -0,0 +1,110 @@
+package valuer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GoObject exposes a Go struct to Lox as an instance.
+// Exported fields are properties which can be read and written,
+// and exported methods can be called.
+// A field can be renamed with a `lox:"name"` tag, and a tag of "-" hides it.
+type GoObject struct {
+	// Value is a pointer to the bound struct.
+	Value reflect.Value
+}
+
+// Bind exposes a struct or a pointer to a struct as a GoObject.
+// A struct passed by value is copied, so assignments from Lox are not visible to the caller.
+func Bind(v interface{}) (*GoObject, error) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct:
+		return &GoObject{Value: rv}, nil
+	case rv.Kind() == reflect.Struct:
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return &GoObject{Value: ptr}, nil
+	}
+	return nil, fmt.Errorf("cannot bind Go type %T, a struct or a pointer to a struct is required", v)
+}
+
+// Type returns its Type.
+func (*GoObject) Type() Type { return InstanceType }
+
+func (o *GoObject) String() string {
+	return o.Value.Elem().Type().Name() + " instance"
+}
+
+// Get returns the field or the method named key.
+func (o *GoObject) Get(key string) (Valuer, error) {
+	if field, ok := o.field(key); ok {
+		return fromReflect(field)
+	}
+	if method := o.Value.MethodByName(key); method.IsValid() {
+		return wrapFunc(key, method)
+	}
+	return nil, fmt.Errorf("undefined property %s", key)
+}
+
+// Set assigns v to the field named key.
+func (o *GoObject) Set(key string, v Valuer) error {
+	field, ok := o.field(key)
+	if !ok {
+		return fmt.Errorf("undefined field %s", key)
+	}
+	rv, err := toReflect(v, field.Type())
+	if err != nil {
+		return fmt.Errorf("field %s: %s", key, err.Error())
+	}
+	field.Set(rv)
+	return nil
+}
+
+// Fields returns names of exposed fields.
+func (o *GoObject) Fields() []string {
+	var names []string
+	typ := o.Value.Elem().Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if name, ok := fieldName(typ.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Methods returns names of exposed methods.
+func (o *GoObject) Methods() []string {
+	typ := o.Value.Type()
+	names := make([]string, typ.NumMethod())
+	for i := range names {
+		names[i] = typ.Method(i).Name
+	}
+	return names
+}
+
+func (o *GoObject) field(key string) (reflect.Value, bool) {
+	elem := o.Value.Elem()
+	typ := elem.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if name, ok := fieldName(typ.Field(i)); ok && name == key {
+			return elem.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		// unexported field.
+		return "", false
+	}
+	tag := field.Tag.Get("lox")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, true
+}
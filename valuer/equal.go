@@ -0,0 +1,43 @@
+package valuer
+
+// IsTruthy reports whether v is true as a condition. Only true, numbers other
+// than 0 and non-empty strings are truthy.
+func IsTruthy(v Valuer) bool {
+	switch v := v.(type) {
+	case *Boolean:
+		return v.Value
+	case *Number:
+		return v.Value != 0
+	case *String:
+		return v.Value != ""
+	}
+	return false
+}
+
+// Equal reports whether a and b are equal by the == operator of Lox.
+func Equal(a, b Valuer) bool {
+	_, ok := a.(*Boolean)
+	_, ok1 := b.(*Boolean)
+	if ok || ok1 {
+		return IsTruthy(a) == IsTruthy(b)
+	}
+
+	switch a1 := a.(type) {
+	case *Number:
+		if b1, ok := b.(*Number); ok {
+			return a1.Value == b1.Value
+		}
+	case *Nil:
+		if _, ok := b.(*Nil); ok {
+			return true
+		}
+	case *String:
+		if b1, ok := b.(*String); ok {
+			return a1.Value == b1.Value
+		}
+	default:
+		// classes, instances, functions and modules are equal only to themselves.
+		return a == b
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package valuer
+
+import "testing"
+
+func TestIsTruthy(t *testing.T) {
+	tests := []struct {
+		input    Valuer
+		expected bool
+	}{
+		{&Boolean{Value: true}, true},
+		{&Boolean{Value: false}, false},
+		{&Number{Value: 1}, true},
+		{&Number{Value: 0}, false},
+		{&String{Value: "s"}, true},
+		{&String{Value: ""}, false},
+		{&Nil{}, false},
+		{&ClassValue{Name: "A"}, false},
+		{nil, false},
+	}
+	for i, tt := range tests {
+		if got := IsTruthy(tt.input); got != tt.expected {
+			t.Errorf("test [%d]: expected %t. got %t", i, tt.expected, got)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	class := &ClassValue{Name: "A"}
+	instance := NewInstance(class)
+	tests := []struct {
+		a, b     Valuer
+		expected bool
+	}{
+		{&Number{Value: 1}, &Number{Value: 1}, true},
+		{&Number{Value: 1}, &String{Value: "1"}, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&Nil{}, &Nil{}, true},
+		{&Nil{}, &Number{Value: 0}, false},
+		{&Boolean{Value: true}, &Number{Value: 1}, true},
+		{&Boolean{Value: false}, &Nil{}, true},
+		{class, class, true},
+		{class, &ClassValue{Name: "A"}, false},
+		{instance, instance, true},
+		{instance, NewInstance(class), false},
+	}
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("test [%d]: expected %t. got %t", i, tt.expected, got)
+		}
+	}
+}
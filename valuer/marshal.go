@@ -0,0 +1,215 @@
+package valuer
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+var (
+	valuerType = reflect.TypeOf((*Valuer)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// FromGo converts a Go value into a Valuer.
+// Booleans, numbers and strings become their Lox counterparts and nil becomes nil.
+// Functions become native functions, structs and pointers to structs are exposed by Bind.
+// A Valuer is returned as it is.
+func FromGo(v interface{}) (Valuer, error) {
+	if v == nil {
+		return &Nil{}, nil
+	}
+	return fromReflect(reflect.ValueOf(v))
+}
+
+// ToGo converts a Valuer into a Go value.
+// Numbers, strings and booleans become float64, string and bool, nil becomes nil,
+// and values created by Bind return the bound Go value. Other values are returned as they are.
+func ToGo(v Valuer) interface{} {
+	switch val := v.(type) {
+	case nil, *Nil:
+		return nil
+	case *Number:
+		return val.Value
+	case *String:
+		return val.Value
+	case *Boolean:
+		return val.Value
+	case *GoObject:
+		return val.Value.Interface()
+	}
+	return v
+}
+
+func fromReflect(rv reflect.Value) (Valuer, error) {
+	if rv.IsValid() && rv.CanInterface() {
+		if v, ok := rv.Interface().(Valuer); ok {
+			return v, nil
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return &Nil{}, nil
+	case reflect.Bool:
+		return &Boolean{Value: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Number{Value: float64(rv.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Number{Value: float64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Number{Value: rv.Float()}, nil
+	case reflect.String:
+		return &String{Value: rv.String()}, nil
+	case reflect.Func:
+		if rv.IsNil() {
+			return &Nil{}, nil
+		}
+		return wrapFunc(funcName(rv), rv)
+	case reflect.Interface:
+		if rv.IsNil() {
+			return &Nil{}, nil
+		}
+		return fromReflect(rv.Elem())
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return &Nil{}, nil
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			return &GoObject{Value: rv}, nil
+		}
+		return fromReflect(rv.Elem())
+	case reflect.Struct:
+		if rv.CanAddr() {
+			return &GoObject{Value: rv.Addr()}, nil
+		}
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return &GoObject{Value: ptr}, nil
+	}
+	return nil, fmt.Errorf("cannot convert Go type %s to Lox value", rv.Type())
+}
+
+// toReflect converts v into a Go value of type typ. An empty interface receives the
+// Go value of v given by ToGo.
+func toReflect(v Valuer, typ reflect.Type) (reflect.Value, error) {
+	if typ.Kind() == reflect.Interface && typ.NumMethod() == 0 {
+		if x := ToGo(v); x != nil {
+			return reflect.ValueOf(x), nil
+		}
+		return reflect.Zero(typ), nil
+	}
+	if v != nil && reflect.TypeOf(v).AssignableTo(typ) {
+		return reflect.ValueOf(v), nil
+	}
+	x := ToGo(v)
+	if x == nil {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(typ), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as Go type %s", typ)
+	}
+	rv := reflect.ValueOf(x)
+	if rv.Type().AssignableTo(typ) {
+		return rv, nil
+	}
+	if n, ok := v.(*Number); ok {
+		return numberToReflect(n.Value, typ)
+	}
+	if rv.Kind() == typ.Kind() && rv.Type().ConvertibleTo(typ) {
+		return rv.Convert(typ), nil
+	}
+	if rv.Kind() == reflect.Ptr && rv.Elem().Type().AssignableTo(typ) {
+		return rv.Elem(), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s value as Go type %s", v.Type(), typ)
+}
+
+func numberToReflect(f float64, typ reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if rv.OverflowFloat(f) {
+			return rv, fmt.Errorf("%v overflows Go type %s", f, typ)
+		}
+		rv.SetFloat(f)
+		return rv, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || rv.OverflowInt(int64(f)) {
+			return rv, fmt.Errorf("%v can't be represented by Go type %s", f, typ)
+		}
+		rv.SetInt(int64(f))
+		return rv, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if f != math.Trunc(f) || f < 0 || rv.OverflowUint(uint64(f)) {
+			return rv, fmt.Errorf("%v can't be represented by Go type %s", f, typ)
+		}
+		rv.SetUint(uint64(f))
+		return rv, nil
+	}
+	return rv, fmt.Errorf("cannot use number value as Go type %s", typ)
+}
+
+// wrapFunc wraps a Go function into a NativeFunction.
+// The function may return at most one value, optionally followed by an error.
+func wrapFunc(name string, fn reflect.Value) (*NativeFunction, error) {
+	typ := fn.Type()
+	numOut := typ.NumOut()
+	returnsErr := numOut > 0 && typ.Out(numOut-1) == errorType
+	if returnsErr {
+		numOut--
+	}
+	if numOut > 1 {
+		return nil, fmt.Errorf("function %s returns more than one value", name)
+	}
+
+	numIn := typ.NumIn()
+	params := numIn
+	if typ.IsVariadic() {
+		params = -1
+	}
+	call := func(args []Valuer) (Valuer, error) {
+		if typ.IsVariadic() && len(args) < numIn-1 {
+			return nil, fmt.Errorf("expected at least %d arguments but got %d", numIn-1, len(args))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			argType := variadicIn(typ, i)
+			v, err := toReflect(arg, argType)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d of %s: %s", i+1, name, err.Error())
+			}
+			in[i] = v
+		}
+		out := fn.Call(in)
+		if returnsErr {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return nil, err
+			}
+		}
+		if numOut == 0 {
+			return &Nil{}, nil
+		}
+		return fromReflect(out[0])
+	}
+	return &NativeFunction{Name: name, NumParams: params, Fn: call}, nil
+}
+
+// variadicIn returns type of i-th argument of function type typ.
+func variadicIn(typ reflect.Type, i int) reflect.Type {
+	if typ.IsVariadic() && i >= typ.NumIn()-1 {
+		return typ.In(typ.NumIn() - 1).Elem()
+	}
+	return typ.In(i)
+}
+
+func funcName(fn reflect.Value) string {
+	f := runtime.FuncForPC(fn.Pointer())
+	if f == nil {
+		return "native"
+	}
+	name := f.Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,170 @@
+package valuer
+
+import (
+	"errors"
+	"testing"
+)
+
+type point struct {
+	X, Y   float64
+	Label  string `lox:"label"`
+	Hidden int    `lox:"-"`
+	secret int
+}
+
+func (p *point) Move(dx, dy float64) {
+	p.X += dx
+	p.Y += dy
+}
+
+func (p point) Sum() float64 { return p.X + p.Y }
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+		typ      Type
+	}{
+		{nil, "nil", NilType},
+		{true, "true", BooleanType},
+		{1, "1", NumberType},
+		{uint8(2), "2", NumberType},
+		{1.5, "1.5", NumberType},
+		{"x", "x", StringType},
+		{&String{Value: "v"}, "v", StringType},
+		{point{}, "point instance", InstanceType},
+		{&point{}, "point instance", InstanceType},
+		{func() {}, "<native fn func1>", FunctionType},
+	}
+
+	for i, test := range tests {
+		v, err := FromGo(test.input)
+		if err != nil {
+			t.Fatalf("test [%d]: error: %s", i, err.Error())
+		}
+		if v.Type() != test.typ {
+			t.Errorf("test [%d]: expected type is %s. got %s", i, test.typ, v.Type())
+		}
+		if v.String() != test.expected {
+			t.Errorf("test [%d]: expected value is %q. got %q", i, test.expected, v.String())
+		}
+	}
+
+	if _, err := FromGo(map[string]int{}); err == nil {
+		t.Errorf("map should not be converted")
+	}
+}
+
+func TestToGo(t *testing.T) {
+	p := &point{}
+	o, _ := Bind(p)
+	tests := []struct {
+		input    Valuer
+		expected interface{}
+	}{
+		{&Nil{}, nil},
+		{&Boolean{Value: true}, true},
+		{&Number{Value: 2}, float64(2)},
+		{&String{Value: "x"}, "x"},
+		{o, p},
+	}
+
+	for i, test := range tests {
+		if v := ToGo(test.input); v != test.expected {
+			t.Errorf("test [%d]: expected value is %v. got %v", i, test.expected, v)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	p := &point{X: 1, Y: 2, Label: "a"}
+	o, err := Bind(p)
+	if err != nil {
+		t.Fatalf("bind failed. error: %s", err.Error())
+	}
+
+	if err := o.Set("X", &Number{Value: 10}); err != nil {
+		t.Fatalf("set X failed. error: %s", err.Error())
+	}
+	if err := o.Set("label", &String{Value: "b"}); err != nil {
+		t.Fatalf("set label failed. error: %s", err.Error())
+	}
+	if p.X != 10 || p.Label != "b" {
+		t.Errorf("assignments should be visible in Go. got %+v", p)
+	}
+	if err := o.Set("X", &String{Value: "x"}); err == nil {
+		t.Errorf("string should not be assigned to float64 field")
+	}
+	for _, key := range []string{"Hidden", "secret", "Label"} {
+		if _, err := o.Get(key); err == nil {
+			t.Errorf("field %s should not be exposed", key)
+		}
+	}
+
+	move, err := o.Get("Move")
+	if err != nil {
+		t.Fatalf("get Move failed. error: %s", err.Error())
+	}
+	fn := move.(*NativeFunction)
+	if fn.Arity() != 2 {
+		t.Errorf("expected arity is 2. got %d", fn.Arity())
+	}
+	if _, err := fn.Fn([]Valuer{&Number{Value: 1}, &Number{Value: 1}}); err != nil {
+		t.Fatalf("call Move failed. error: %s", err.Error())
+	}
+	sum, _ := o.Get("Sum")
+	v, err := sum.(*NativeFunction).Fn(nil)
+	if err != nil {
+		t.Fatalf("call Sum failed. error: %s", err.Error())
+	}
+	if v.String() != "14" {
+		t.Errorf("expected sum is 14. got %s", v)
+	}
+
+	if _, err := Bind(1); err == nil {
+		t.Errorf("number should not be bound")
+	}
+}
+
+func TestNativeFunctionError(t *testing.T) {
+	fail := errors.New("fail")
+	v, _ := FromGo(func(n int) (int, error) {
+		if n < 0 {
+			return 0, fail
+		}
+		return n * 2, nil
+	})
+	fn := v.(*NativeFunction)
+	if v, err := fn.Fn([]Valuer{&Number{Value: 2}}); err != nil || v.String() != "4" {
+		t.Errorf("expected result is 4. got %v (%v)", v, err)
+	}
+	if _, err := fn.Fn([]Valuer{&Number{Value: -1}}); err != fail {
+		t.Errorf("expected error is %v. got %v", fail, err)
+	}
+	if _, err := fn.Fn([]Valuer{&Number{Value: 1.5}}); err == nil {
+		t.Errorf("1.5 should not be converted to int")
+	}
+}
+
+func TestNativeFunctionInterface(t *testing.T) {
+	var got []interface{}
+	v, _ := FromGo(func(args ...interface{}) {
+		got = append(got, args...)
+	})
+	fn := v.(*NativeFunction)
+	o, _ := Bind(&point{})
+	f := &Function{Name: "f"}
+	args := []Valuer{&Number{Value: 1.5}, &String{Value: "s"}, &Boolean{Value: true}, &Nil{}, o, f}
+	if _, err := fn.Fn(args); err != nil {
+		t.Fatalf("call failed. error: %s", err.Error())
+	}
+	expected := []interface{}{1.5, "s", true, nil, ToGo(o), f}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d arguments. got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("argument %d: expected %#v. got %#v", i, expected[i], got[i])
+		}
+	}
+}
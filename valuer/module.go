@@ -0,0 +1,29 @@
+package valuer
+
+import "strings"
+
+// Module is a Lox file imported by another one. Its members are the global names
+// of the file, except those starting with "_".
+type Module struct {
+	// Name is the path by which the module is imported.
+	Name string
+	// File is the absolute name of the file.
+	File    string
+	Globals *Environment
+}
+
+// Type returns its Type.
+func (*Module) Type() Type { return ModuleType }
+
+func (m *Module) String() string {
+	return "<module " + m.Name + ">"
+}
+
+// Get returns the exported global named key.
+func (m *Module) Get(key string) (Valuer, bool) {
+	if strings.HasPrefix(key, "_") {
+		return nil, false
+	}
+	v, ok := m.Globals.Values[key]
+	return v, ok
+}
@@ -0,0 +1,23 @@
+package valuer
+
+// NativeFunction represents a function implemented in Go.
+type NativeFunction struct {
+	Name string
+	// NumParams is the number of parameters. -1 means any number of arguments.
+	NumParams int
+	Fn        func(args []Valuer) (Valuer, error)
+}
+
+// Type returns its Type.
+func (*NativeFunction) Type() Type { return FunctionType }
+
+func (*NativeFunction) call() {}
+
+func (fn *NativeFunction) String() string {
+	return "<native fn " + fn.Name + ">"
+}
+
+// Arity returns number of params.
+func (fn *NativeFunction) Arity() int {
+	return fn.NumParams
+}
@@ -0,0 +1,56 @@
+package valuer
+
+// Shape is the layout of the fields of instances, which maps names of fields to
+// slots of Instance.Fields. An instance starts with the root shape of its class,
+// and adding a field moves it to the shape with the field added. Instances of a
+// class adding the same fields in the same order share shapes, so that lookups
+// can be cached by shape.
+type Shape struct {
+	// Class is the class of instances of the shape.
+	Class       *ClassValue
+	names       []string
+	slots       map[string]int
+	transitions map[string]*Shape
+}
+
+// Slot returns the slot of the field name, or -1 if there is no such field.
+func (s *Shape) Slot(name string) int {
+	if slot, ok := s.slots[name]; ok {
+		return slot
+	}
+	return -1
+}
+
+// Names returns the names of fields in the order of their slots. It must not be modified.
+func (s *Shape) Names() []string {
+	return s.names
+}
+
+// Add returns the shape with the field name added after the fields of s.
+func (s *Shape) Add(name string) *Shape {
+	if next, ok := s.transitions[name]; ok {
+		return next
+	}
+	next := &Shape{
+		Class: s.Class,
+		names: append(s.names[:len(s.names):len(s.names)], name),
+		slots: make(map[string]int, len(s.slots)+1),
+	}
+	for k, v := range s.slots {
+		next.slots[k] = v
+	}
+	next.slots[name] = len(s.names)
+	if s.transitions == nil {
+		s.transitions = make(map[string]*Shape)
+	}
+	s.transitions[name] = next
+	return next
+}
+
+// Shape returns the root shape of c, which has no fields.
+func (c *ClassValue) Shape() *Shape {
+	if c.shape == nil {
+		c.shape = &Shape{Class: c}
+	}
+	return c.shape
+}
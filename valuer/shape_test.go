@@ -0,0 +1,27 @@
+package valuer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	c := &ClassValue{Name: "A"}
+	a, b := NewInstance(c), NewInstance(c)
+	a.Set("x", &Number{Value: 1})
+	a.Set("y", &Number{Value: 2})
+	b.Set("x", &Number{Value: 3})
+	b.Set("y", &Number{Value: 4})
+	b.Set("x", &Number{Value: 5})
+	if a.Shape != b.Shape || a.Shape.Slot("y") != 1 || a.Shape.Slot("z") != -1 {
+		t.Errorf("instances with the same fields don't share a shape")
+	}
+	if v, _ := b.Field("x"); v.String() != "5" {
+		t.Errorf("field x wrong. got %v", v)
+	}
+	other := &Instance{Klass: c}
+	other.Set("y", &Nil{})
+	if other.Shape == a.Shape || strings.Join(other.FieldNames(), ",") != "y" {
+		t.Errorf("shape of fields added in other order wrong. got %v", other.FieldNames())
+	}
+}
@@ -1,11 +1,15 @@
 package valuer
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/ziyoung/lox-go/ast"
 )
 
+var errNoCaller = errors.New("no interpreter is available to call functions")
+
 var typeMap = map[Type]string{
 	NumberType:   "number",
 	StringType:   "string",
@@ -14,6 +18,8 @@ var typeMap = map[Type]string{
 	FunctionType: "function",
 	ReturnType:   "return",
 	ClassType:    "class",
+	InstanceType: "instance",
+	ModuleType:   "module",
 }
 
 // Type represents type of Valuer.
@@ -28,6 +34,7 @@ const (
 	ReturnType                   // return
 	ClassType                    // class
 	InstanceType                 // instance
+	ModuleType                   // module
 )
 
 func (typ Type) String() string {
@@ -47,6 +54,22 @@ type Callable interface {
 	Arity() int
 }
 
+// caller is used by Call methods to invoke functions and classes.
+// It is registered by the interpreter.
+var caller func(callee Valuer, args ...Valuer) (Valuer, error)
+
+// SetCaller registers fn as the function which invokes functions and classes.
+func SetCaller(fn func(callee Valuer, args ...Valuer) (Valuer, error)) {
+	caller = fn
+}
+
+func callValue(callee Valuer, args []Valuer) (Valuer, error) {
+	if caller == nil {
+		return nil, errNoCaller
+	}
+	return caller(callee, args...)
+}
+
 type Number struct {
 	Value float64
 }
@@ -84,7 +107,11 @@ func (*Nil) Type() Type { return NilType }
 func (*Nil) String() string { return "nil" }
 
 type Function struct {
-	Name          string
+	Name string
+	// Class is the name of the class declaring the method, empty for functions.
+	Class string
+	// Module is the module declaring the function, nil for the main program.
+	Module        *Module
 	Params        []*ast.Ident
 	Body          []ast.Stmt
 	Closure       *Environment
@@ -100,22 +127,41 @@ func (fn *Function) String() string {
 	return "<fn " + fn.Name + ">"
 }
 
+// QualifiedName returns the name qualified by the class and the module declaring
+// the function, such as math.Vector.add.
+func (fn *Function) QualifiedName() string {
+	name := fn.Name
+	if fn.Class != "" {
+		name = fn.Class + "." + name
+	}
+	if fn.Module != nil {
+		name = strings.TrimSuffix(fn.Module.Name, ".lox") + "." + name
+	}
+	return name
+}
+
 // Arity returns size of params.
 func (fn *Function) Arity() int {
 	return len(fn.Params)
 }
 
 func (fn *Function) Bind(instance *Instance) *Function {
-	environment := NewEnclosing(fn.Closure)
-	environment.Define("this", instance)
 	return &Function{
-		Name:    fn.Name,
-		Params:  fn.Params,
-		Body:    fn.Body,
-		Closure: environment,
+		Name:          fn.Name,
+		Class:         fn.Class,
+		Module:        fn.Module,
+		Params:        fn.Params,
+		Body:          fn.Body,
+		Closure:       instance.Bound(fn.Closure),
+		IsInitializer: fn.IsInitializer,
 	}
 }
 
+// Call calls the function with already evaluated args.
+func (fn *Function) Call(args ...Valuer) (Valuer, error) {
+	return callValue(fn, args)
+}
+
 type ReturnValue struct {
 	Value Valuer
 }
@@ -130,6 +176,7 @@ func (rt *ReturnValue) String() string {
 type ClassValue struct {
 	Name    string
 	Mehtods map[string]*Function
+	shape   *Shape
 }
 
 func (*ClassValue) Type() Type { return ClassType }
@@ -155,9 +202,23 @@ func (c *ClassValue) FindMethod(key string) *Function {
 	return nil
 }
 
+// Call constructs an instance of the class with already evaluated args.
+func (c *ClassValue) Call(args ...Valuer) (Valuer, error) {
+	return callValue(c, args)
+}
+
 type Instance struct {
-	Klass  *ClassValue
-	Fileds map[string]Valuer
+	Klass *ClassValue
+	// Shape is the layout of Fields. A nil Shape is the root shape of Klass.
+	Shape  *Shape
+	Fields []Valuer
+	// this binds this to the instance for methods of Klass.
+	this *Environment
+}
+
+// NewInstance returns an instance of c without fields.
+func NewInstance(c *ClassValue) *Instance {
+	return &Instance{Klass: c, Shape: c.Shape()}
 }
 
 func (*Instance) Type() Type { return ClassType }
@@ -166,8 +227,30 @@ func (i *Instance) String() string {
 	return i.Klass.Name + " instance"
 }
 
+// Layout returns the shape of i.
+func (i *Instance) Layout() *Shape {
+	if i.Shape == nil {
+		i.Shape = i.Klass.Shape()
+	}
+	return i.Shape
+}
+
+// Field returns the field key of i.
+func (i *Instance) Field(key string) (Valuer, bool) {
+	if slot := i.Layout().Slot(key); slot >= 0 {
+		return i.Fields[slot], true
+	}
+	return nil, false
+}
+
+// FieldNames returns the names of the fields of i in the order they are added.
+func (i *Instance) FieldNames() []string {
+	return i.Layout().Names()
+}
+
+// Get returns the field key of i, or the method key bound to i.
 func (i *Instance) Get(key string) (Valuer, bool) {
-	if v, ok := i.Fileds[key]; ok {
+	if v, ok := i.Field(key); ok {
 		return v, ok
 	}
 	if method := i.Klass.FindMethod(key); method != nil {
@@ -177,8 +260,21 @@ func (i *Instance) Get(key string) (Valuer, bool) {
 }
 
 func (i *Instance) Set(key string, v Valuer) {
-	if i.Fileds == nil {
-		i.Fileds = make(map[string]Valuer)
+	shape := i.Layout()
+	if slot := shape.Slot(key); slot >= 0 {
+		i.Fields[slot] = v
+		return
+	}
+	i.Shape = shape.Add(key)
+	i.Fields = append(i.Fields, v)
+}
+
+// Bound returns the environment enclosed by closure which binds this to i. It is
+// the closure of methods bound to i, and is reused while closure is the same.
+func (i *Instance) Bound(closure *Environment) *Environment {
+	if i.this == nil || i.this.Enclosing != closure {
+		i.this = NewEnclosing(closure)
+		i.this.Define("this", i)
 	}
-	i.Fileds[key] = v
+	return i.this
 }